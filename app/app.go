@@ -5,9 +5,14 @@ package app
 import (
 	"errors"
 	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
 
+	"github.com/nkarpenko/koho-transaction/common/stream"
 	"github.com/nkarpenko/koho-transaction/conf"
 	"github.com/nkarpenko/koho-transaction/parser"
+	"github.com/nkarpenko/koho-transaction/registry"
 	"github.com/nkarpenko/koho-transaction/transaction"
 	"github.com/nkarpenko/koho-transaction/validator"
 )
@@ -22,14 +27,51 @@ type App struct {
 	transaction transaction.Transaction
 }
 
-// Start the application.
-func (a *App) Start() {
+// InputError struct is returned by Start when the input can't be read or
+// parsed.
+type InputError struct {
+	Err error
+}
+
+// Error implements the error interface.
+func (e *InputError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *InputError) Unwrap() error {
+	return e.Err
+}
+
+// Start the application. It returns the run summary, or an error if the run
+// could not be completed.
+func (a *App) Start() (*Summary, error) {
+	summary := newSummary()
 
 	// Parse input file to retrieve all the .
 	txs, err := a.parser.ParseFile()
 	if err != nil {
-		fmt.Printf("Failed to parse file. Error: %v\n", err)
-		return
+		return nil, &InputError{Err: err}
+	}
+
+	// Report any records rejected by strict schema validation.
+	for _, rejected := range a.parser.Rejected() {
+		fmt.Fprintf(os.Stderr, "Rejected record %v\n", rejected)
+	}
+	summary.ParseErrors = len(a.parser.Rejected())
+	summary.RecordsRead = len(*txs) + summary.ParseErrors
+
+	// Open the output sink, compressing it if configured.
+	out, err := stream.Create(a.config.OutputFile, a.config.OutputCompression)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open output: %w", err)
+	}
+	a.transaction.SetOutput(out)
+
+	// Reload the customer registry whenever the process is sent SIGHUP.
+	if a.config.Registry != nil {
+		stop := reloadOnHangup(a.config.Registry)
+		defer stop()
 	}
 
 	// Loop through each transaction and try to validate + process it.
@@ -39,7 +81,48 @@ func (a *App) Start() {
 		res := a.transaction.Validate(&tx)
 
 		// Process the transaction.
-		a.transaction.Process(res)
+		if err := a.transaction.Process(res); err != nil {
+			out.Close()
+			return nil, fmt.Errorf("failed to write result: %w", err)
+		}
+		summary.add(res)
+	}
+
+	// Flush the output before reporting the run totals.
+	if err := out.Close(); err != nil {
+		return nil, fmt.Errorf("failed to write output: %w", err)
+	}
+	summary.finish()
+	if err := summary.save(a.config.SummaryFile); err != nil {
+		return summary, fmt.Errorf("failed to write summary: %w", err)
+	}
+
+	return summary, nil
+}
+
+// reloadOnHangup reloads the customer registry each time the process is sent
+// SIGHUP, until the returned stop function is called.
+func reloadOnHangup(reg *registry.Registry) (stop func()) {
+	sig := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(sig, syscall.SIGHUP)
+
+	go func() {
+		for {
+			select {
+			case <-sig:
+				if err := reg.Reload(); err != nil {
+					fmt.Fprintf(os.Stderr, "Failed to reload registry. Error: %v\n", err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(sig)
+		close(done)
 	}
 }
 
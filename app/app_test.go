@@ -1,8 +1,14 @@
 package app
 
 import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
+	txcache "github.com/nkarpenko/koho-transaction/common/cache"
 	"github.com/nkarpenko/koho-transaction/common/model"
 	"github.com/nkarpenko/koho-transaction/conf"
 )
@@ -47,7 +53,11 @@ func TestStart(t *testing.T) {
 			t.Errorf("unable to initialize app: %+v", err)
 		}
 
-		app.Start()
+		// The test inputs don't exist so the run fails on its input.
+		var inputErr *InputError
+		if _, err := app.Start(); !errors.As(err, &inputErr) {
+			t.Errorf("expected an input error, got '%+v'", err)
+		}
 	}
 
 }
@@ -88,3 +98,87 @@ func TestNew(t *testing.T) {
 		}
 	}
 }
+
+func TestSummary(t *testing.T) {
+	dir := t.TempDir()
+	summaryFile := filepath.Join(dir, "summary.json")
+
+	app, err := New(&conf.Config{
+		InputFile:   "../input.txt",
+		OutputFile:  filepath.Join(dir, "output.txt"),
+		SummaryFile: summaryFile,
+		Store:       txcache.New(),
+		Limits: &model.Limits{
+			DailyAmount:       5000,
+			DailyTransactions: 3,
+			WeeklyAmount:      20000,
+		},
+	})
+	if err != nil {
+		t.Fatalf("unable to initialize app: %+v", err)
+	}
+
+	// Confirm the returned totals.
+	summary, err := app.Start()
+	if err != nil {
+		t.Fatalf("unable to run app: %+v", err)
+	}
+	if summary.RecordsRead != 1000 || summary.Duplicates != 1 || summary.Accepted != 762 || summary.Rejected != 237 {
+		t.Errorf("unexpected run totals '%+v'", summary)
+	}
+	if summary.Reasons[model.ReasonDailyAmountLimit] != 233 || summary.Customers != 50 {
+		t.Errorf("unexpected reasons or customers '%+v'", summary)
+	}
+
+	// Confirm the summary file was written.
+	b, err := os.ReadFile(summaryFile)
+	if err != nil {
+		t.Fatalf("unable to read summary: %+v", err)
+	}
+	var saved Summary
+	if err := json.Unmarshal(b, &saved); err != nil || saved.Accepted != summary.Accepted {
+		t.Errorf("expected saved summary to match, got '%s'", b)
+	}
+}
+
+func TestSummaryAcceptedAmount(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "input.txt")
+
+	// A voided hold, a reversed load and a partly captured hold.
+	data := `{"id":"1","customer_id":"9130","load_amount":"$600.00","time":"1998-02-02T00:00:00Z","type":"authorization"}
+{"id":"2","customer_id":"9130","reference_id":"1","time":"1998-02-02T00:01:00Z","type":"void"}
+{"id":"3","customer_id":"9130","load_amount":"$500.00","time":"1998-02-02T00:02:00Z"}
+{"id":"4","customer_id":"9130","reference_id":"3","time":"1998-02-02T00:03:00Z","type":"reversal"}
+{"id":"5","customer_id":"9130","load_amount":"$800.00","time":"1998-02-02T00:04:00Z","type":"authorization"}
+{"id":"6","customer_id":"9130","load_amount":"$300.00","reference_id":"5","time":"1998-02-02T00:05:00Z","type":"capture"}
+`
+	if err := os.WriteFile(input, []byte(data), 0644); err != nil {
+		t.Fatalf("unable to write test file: %+v", err)
+	}
+
+	app, err := New(&conf.Config{
+		InputFile:   input,
+		OutputFile:  filepath.Join(dir, "output.txt"),
+		SummaryFile: filepath.Join(dir, "summary.json"),
+		Store:       txcache.New(),
+		HoldExpiry:  time.Hour,
+		Limits: &model.Limits{
+			DailyAmount:       5000,
+			DailyTransactions: 5,
+			WeeklyAmount:      20000,
+		},
+	})
+	if err != nil {
+		t.Fatalf("unable to initialize app: %+v", err)
+	}
+
+	// Only the captured $300 stays settled.
+	summary, err := app.Start()
+	if err != nil {
+		t.Fatalf("unable to run app: %+v", err)
+	}
+	if summary.Accepted != 6 || summary.AcceptedAmount != 300 {
+		t.Errorf("expected 6 accepted and $300 settled, got '%+v'", summary)
+	}
+}
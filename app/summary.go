@@ -0,0 +1,109 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/nkarpenko/koho-transaction/common/model"
+)
+
+// Summary struct holds the totals of a single run. AcceptedAmount is the net
+// amount settled: accepted loads and captures less reversals.
+type Summary struct {
+	RecordsRead    int            `json:"records_read"`
+	ParseErrors    int            `json:"parse_errors"`
+	Duplicates     int            `json:"duplicates_ignored"`
+	Accepted       int            `json:"accepted"`
+	Rejected       int            `json:"rejected"`
+	Reasons        map[string]int `json:"rejected_by_reason"`
+	AcceptedAmount float64        `json:"accepted_amount"`
+	Customers      int            `json:"distinct_customers"`
+	ElapsedSeconds float64        `json:"elapsed_seconds"`
+	Throughput     float64        `json:"records_per_second"`
+
+	customers map[int]bool
+	started   time.Time
+}
+
+// newSummary starts a run summary.
+func newSummary() *Summary {
+	return &Summary{
+		Reasons:   map[string]int{},
+		customers: map[int]bool{},
+		started:   time.Now(),
+	}
+}
+
+// add counts a single processed result.
+func (s *Summary) add(res *model.Result) {
+	s.customers[res.CustomerID] = true
+
+	switch {
+	case res.IgnoreMessage:
+		s.Duplicates++
+	case res.Accepted:
+		s.Accepted++
+		s.AcceptedAmount = s.AcceptedAmount + res.SettledAmount()
+	default:
+		s.Rejected++
+		s.Reasons[res.Reason]++
+	}
+}
+
+// finish records the distinct customers, elapsed time and throughput.
+func (s *Summary) finish() {
+	s.Customers = len(s.customers)
+
+	elapsed := time.Since(s.started)
+	s.ElapsedSeconds = elapsed.Seconds()
+	if elapsed > 0 {
+		s.Throughput = float64(s.RecordsRead) / elapsed.Seconds()
+	}
+}
+
+// Write prints the summary in a readable form.
+func (s *Summary) Write(w io.Writer) {
+	fmt.Fprintln(w, "Run summary:")
+	fmt.Fprintf(w, "\trecords read: %d\n", s.RecordsRead)
+	fmt.Fprintf(w, "\tparse errors: %d\n", s.ParseErrors)
+	fmt.Fprintf(w, "\tduplicates ignored: %d\n", s.Duplicates)
+	fmt.Fprintf(w, "\taccepted: %d ($%.2f)\n", s.Accepted, s.AcceptedAmount)
+	fmt.Fprintf(w, "\trejected: %d\n", s.Rejected)
+
+	// List the rejection reasons in a stable order.
+	reasons := make([]string, 0, len(s.Reasons))
+	for reason := range s.Reasons {
+		reasons = append(reasons, reason)
+	}
+	sort.Strings(reasons)
+	for _, reason := range reasons {
+		fmt.Fprintf(w, "\t\t%s: %d\n", reason, s.Reasons[reason])
+	}
+
+	fmt.Fprintf(w, "\tdistinct customers: %d\n", s.Customers)
+	fmt.Fprintf(w, "\telapsed: %.3fs (%.0f records/s)\n", s.ElapsedSeconds, s.Throughput)
+}
+
+// save writes the summary as JSON to the given path, or in a readable form
+// to stderr when no path is given.
+func (s *Summary) save(path string) error {
+	if path == "" {
+		s.Write(os.Stderr)
+		return nil
+	}
+
+	// Create the summary file.
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	enc := json.NewEncoder(file)
+	enc.SetIndent("", "  ")
+	return enc.Encode(s)
+}
@@ -0,0 +1,85 @@
+// Package balance contains the opening account balances a customer's running
+// balance is counted from.
+package balance
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Balances struct holds the opening balance of every known customer in the
+// limit currency.
+type Balances struct {
+	opening map[int]float64
+}
+
+// Opening method returns the customer's opening balance. Customers without
+// one start from zero.
+func (b *Balances) Opening(customerID int) float64 {
+	if b == nil {
+		return 0
+	}
+
+	return b.opening[customerID]
+}
+
+// Load reads a CSV opening balances file with a "customer_id,balance" header,
+// for example "528,1250.00".
+func Load(path string) (*Balances, error) {
+
+	// Try and open the balances file.
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return Read(file)
+}
+
+// Read parses CSV opening balances from r.
+func Read(r io.Reader) (*Balances, error) {
+	opening := map[int]float64{}
+
+	// Read every record, including the header.
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, errors.New("balances file is empty")
+	}
+
+	// Convert each record after the header into an opening balance.
+	for i, record := range records[1:] {
+		if len(record) != 2 {
+			return nil, fmt.Errorf("balances line %d: expected customer_id and balance", i+2)
+		}
+
+		customerID, err := strconv.Atoi(strings.TrimSpace(record[0]))
+		if err != nil {
+			return nil, fmt.Errorf("balances line %d: invalid customer id %q", i+2, record[0])
+		}
+		amount, err := strconv.ParseFloat(strings.TrimSpace(record[1]), 64)
+		if err != nil {
+			return nil, fmt.Errorf("balances line %d: balance must be a number", i+2)
+		}
+		if _, ok := opening[customerID]; ok {
+			return nil, fmt.Errorf("balances line %d: customer %d is listed more than once", i+2, customerID)
+		}
+
+		opening[customerID] = amount
+	}
+
+	return New(opening), nil
+}
+
+// New opening balances from a map of customer ids to balances.
+func New(opening map[int]float64) *Balances {
+	return &Balances{opening: opening}
+}
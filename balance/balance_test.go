@@ -0,0 +1,41 @@
+package balance
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRead(t *testing.T) {
+	b, err := Read(strings.NewReader("customer_id,balance\n528,1250.50\n154,0\n"))
+	if err != nil {
+		t.Fatalf("unable to read balances: %+v", err)
+	}
+
+	// Confirm listed and unlisted customers.
+	if got := b.Opening(528); got != 1250.50 {
+		t.Errorf("expected opening balance 1250.50, got %v", got)
+	}
+	if got := b.Opening(1); got != 0 {
+		t.Errorf("expected unlisted customer to start from zero, got %v", got)
+	}
+
+	// Initialize invalid balance files.
+	invalid := []string{
+		"",
+		"customer_id,balance\nabc,10\n",
+		"customer_id,balance\n528,ten\n",
+		"customer_id,balance\n528\n",
+		"customer_id,balance\n528,10\n528,20\n",
+	}
+	for _, data := range invalid {
+		if _, err := Read(strings.NewReader(data)); err == nil {
+			t.Errorf("expected balances %q to fail", data)
+		}
+	}
+
+	// Nil balances start everyone from zero.
+	var none *Balances
+	if got := none.Opening(528); got != 0 {
+		t.Errorf("expected nil balances to be zero, got %v", got)
+	}
+}
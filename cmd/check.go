@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/nkarpenko/koho-transaction/common/model"
+	"github.com/nkarpenko/koho-transaction/conf"
+	"github.com/nkarpenko/koho-transaction/parser"
+	"github.com/nkarpenko/koho-transaction/transaction"
+	"github.com/nkarpenko/koho-transaction/validator"
+	"github.com/spf13/cobra"
+)
+
+// Create the check command.
+var checkCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Check whether a single load would be accepted without recording it.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		// Get the config file.
+		c, err := getConfig(cmd)
+		if err != nil {
+			return err
+		}
+
+		// Build the hypothetical load from the flags.
+		tx, err := checkTransaction(cmd, c)
+		if err != nil {
+			return fmt.Errorf("invalid CLI flags, please use the -h flag to see all available options: %w", err)
+		}
+
+		// Replay the customer history first so the limits see earlier loads.
+		history, _ := cmd.Flags().GetString("history")
+		if history != "" {
+			if err := replayInputs(c, []string{history}); err != nil {
+				return inputError(err)
+			}
+		}
+
+		// Evaluate every rule without recording the load.
+		res, evals := validator.New(c).Evaluate(tx)
+		printDecision(res)
+		fmt.Println("Rules:")
+		for _, eval := range evals {
+			printEvaluation(eval)
+		}
+		return nil
+	},
+}
+
+func init() {
+	checkCmd.Flags().Int("customer", 0, "Customer id to check the load for.")
+	checkCmd.Flags().String("amount", "", "Load amount, for example 1500 or \"USD 1,500.00\".")
+	checkCmd.Flags().String("time", "", "Load time in any supported input format. Defaults to now.")
+	checkCmd.Flags().String("history", "", "Input file of earlier transactions to replay before the check.")
+	checkCmd.MarkFlagRequired("customer")
+	checkCmd.MarkFlagRequired("amount")
+}
+
+// checkTransaction builds the load to check from the command flags.
+func checkTransaction(cmd *cobra.Command, c *conf.Config) (*model.Transaction, error) {
+	tx := &model.Transaction{Type: model.TypeLoad, Time: time.Now().UTC()}
+
+	// Read the customer and amount.
+	customerID, err := cmd.Flags().GetInt("customer")
+	if err != nil {
+		return nil, err
+	}
+	tx.CustomerID = customerID
+
+	amount, _ := cmd.Flags().GetString("amount")
+	tx.LoadAmount, tx.Currency, err = model.ParseMoney(amount)
+	if err != nil {
+		return nil, fmt.Errorf("amount %v", err)
+	}
+
+	// Parse the time with the same formats as the input.
+	if at, _ := cmd.Flags().GetString("time"); at != "" {
+		decoder := &model.Decoder{Layouts: c.TimeLayouts}
+		if tx.Time, err = decoder.ParseTime(at); err != nil {
+			return nil, err
+		}
+	}
+
+	return tx, nil
+}
+
+// replayInputs validates and records every transaction in the inputs without
+// writing any results.
+func replayInputs(c *conf.Config, inputs []string) error {
+	_, err := replayUntil(c, inputs, nil)
+	return err
+}
+
+// replayUntil validates and records the transactions in the inputs without
+// writing any results, stopping before the first transaction stop matches.
+// The matched transaction is returned, or nil if nothing matched.
+func replayUntil(c *conf.Config, inputs []string, stop func(*model.Transaction) bool) (*model.Transaction, error) {
+
+	// Parse the inputs with a copy of the config pointed at them.
+	rc := *c
+	rc.InputFiles = inputs
+	txs, err := parser.New(&rc).ParseFile()
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate and process each transaction in turn.
+	t := transaction.New(c)
+	t.SetOutput(io.Discard)
+	for i := range *txs {
+		tx := &(*txs)[i]
+		if stop != nil && stop(tx) {
+			return tx, nil
+		}
+		if err := t.Process(t.Validate(tx)); err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, nil
+}
+
+// printDecision prints whether the checked load would be accepted.
+func printDecision(res *model.Result) {
+	fmt.Printf("Load of $%.2f for customer %d at %s:\n", res.LoadAmount, res.CustomerID, res.Time.Format(time.RFC3339))
+	if res.Accepted {
+		fmt.Println("\taccepted")
+		return
+	}
+	fmt.Printf("\trejected, %s (%s)\n", res.Message, res.Reason)
+}
+
+// printEvaluation prints a single rule outcome with any remaining headroom.
+func printEvaluation(eval model.Evaluation) {
+	outcome := "pass"
+	if !eval.Passed {
+		outcome = "FAIL"
+	}
+
+	// Rules without a limit have no headroom to show.
+	if eval.Limit <= 0 {
+		fmt.Printf("\t%s %s\n", outcome, eval.Rule)
+		return
+	}
+
+	if eval.Count {
+		fmt.Printf("\t%s %s: %.0f of %.0f loads used, %.0f left\n", outcome, eval.Rule, eval.Used, eval.Limit, eval.Remaining)
+		return
+	}
+	fmt.Printf("\t%s %s: $%.2f of $%.2f used, $%.2f left\n", outcome, eval.Rule, eval.Used, eval.Limit, eval.Remaining)
+}
@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// Create the config command.
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Inspect the configuration file.",
+}
+
+// Create the config validate command.
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "Validate the configuration file and report every problem found.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		// Loading the config validates it.
+		c, err := getConfig(cmd)
+		if err != nil {
+			return err
+		}
+
+		if c.File == "" {
+			fmt.Println("Config from the environment and flags is valid.")
+			return nil
+		}
+		fmt.Printf("%s is valid.\n", c.File)
+		return nil
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configValidateCmd)
+}
@@ -0,0 +1,62 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/nkarpenko/koho-transaction/replay"
+	"github.com/spf13/cobra"
+)
+
+// Create the diff command.
+var diffCmd = &cobra.Command{
+	Use:   "diff",
+	Short: "Compare the results of the input to an expected results file.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		// Get the config file.
+		c, err := getConfig(cmd)
+		if err != nil {
+			return err
+		}
+
+		// Run the input in a store of its own.
+		results, err := replay.Run(c)
+		if err != nil {
+			return inputError(err)
+		}
+
+		// Compare the results to the expected file.
+		path, _ := cmd.Flags().GetString("expected")
+		expected, err := os.Open(path)
+		if err != nil {
+			return inputError(err)
+		}
+		defer expected.Close()
+
+		diff, err := replay.Diff(results, expected)
+		if err != nil {
+			return inputError(err)
+		}
+
+		// Report every difference and fail if there were any.
+		for _, key := range diff.Missing {
+			fmt.Printf("missing: %d for customer %d\n", key.ID, key.CustomerID)
+		}
+		for _, key := range diff.Extra {
+			fmt.Printf("extra: %d for customer %d\n", key.ID, key.CustomerID)
+		}
+		for _, m := range diff.Mismatched {
+			fmt.Printf("mismatched: %d for customer %d, expected accepted %t, got %t\n", m.Key.ID, m.Key.CustomerID, m.Expected, m.Actual)
+		}
+		if !diff.Empty() {
+			return fmt.Errorf("%d missing, %d extra and %d mismatched results", len(diff.Missing), len(diff.Extra), len(diff.Mismatched))
+		}
+		fmt.Printf("All %d results match.\n", len(results))
+		return nil
+	},
+}
+
+func init() {
+	diffCmd.Flags().String("expected", "expected_output.txt", "Expected results file, one JSON result per line.")
+}
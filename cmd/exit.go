@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Process exit codes.
+const (
+	ExitSuccess        = 0 // every record was processed
+	ExitFailure        = 1 // any other failure, such as invalid flags or output errors
+	ExitConfigError    = 2 // the config could not be loaded
+	ExitInputError     = 3 // the input could not be read or parsed
+	ExitPartialFailure = 4 // the run finished but skipped invalid input records
+)
+
+// ExitError struct pairs a command error with the code the process should
+// exit with.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+// Error implements the error interface.
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode returns the code the process should exit with for an error
+// returned by a command.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitSuccess
+	}
+
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+
+	return ExitFailure
+}
+
+// configError wraps a failure to load the config.
+func configError(err error) error {
+	return &ExitError{Code: ExitConfigError, Err: fmt.Errorf("failed to load configuration: %w", err)}
+}
+
+// inputError wraps a failure to read or parse the input.
+func inputError(err error) error {
+	return &ExitError{Code: ExitInputError, Err: fmt.Errorf("failed to read input: %w", err)}
+}
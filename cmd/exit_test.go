@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type test struct {
+	name  string
+	input string
+	args  []string
+	code  int
+}
+
+func TestExitCode(t *testing.T) {
+	dir := t.TempDir()
+
+	// Write a config whose input and output stay inside the test directory.
+	config := filepath.Join(dir, "config.yml")
+	err := os.WriteFile(config, []byte(`limits:
+  daily_amount: 5000
+  weekly_amount: 20000
+  daily_transactions: 3
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Initialize test cases, each with its own customers in the shared cache.
+	valid := `{"id":"1","customer_id":"9120","load_amount":"$100.00","time":"1999-01-04T00:00:00Z"}`
+	invalid := `{"id":"2","customer_id":"9121","load_amount":"$100.00","time":"yesterday"}`
+	tests := []test{
+		{
+			name:  "success",
+			input: valid + "\n",
+			code:  ExitSuccess,
+		},
+		{
+			name: "config error",
+			args: []string{"-c", filepath.Join(dir, "missing.yml")},
+			code: ExitConfigError,
+		},
+		{
+			name: "invalid config",
+			args: []string{"--daily-amount", "-1"},
+			code: ExitConfigError,
+		},
+		{
+			name:  "parse failure",
+			input: invalid + "\n",
+			code:  ExitInputError,
+		},
+		{
+			name:  "strict skipped records",
+			input: `{"id":"3","customer_id":"9122","load_amount":"$100.00","time":"1999-01-04T00:00:00Z"}` + "\n" + invalid + "\n",
+			args:  []string{"--set", "strict=true"},
+			code:  ExitPartialFailure,
+		},
+		{
+			name: "other failure",
+			args: []string{"--unknown-flag"},
+			code: ExitFailure,
+		},
+	}
+
+	// Run test cases.
+	for i, test := range tests {
+		input := filepath.Join(dir, "input.txt")
+		if err := os.WriteFile(input, []byte(test.input), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		args := []string{
+			"-c", config,
+			"-i", input,
+			"-o", filepath.Join(dir, "output.txt"),
+			"--set", "summary=" + filepath.Join(dir, "summary.json"),
+		}
+		root := RootCmd()
+		root.SetArgs(append(args, test.args...))
+		err := root.Execute()
+		if code := ExitCode(err); code != test.code {
+			t.Errorf("test %d (%s): expected exit code %d, got %d: %v", i, test.name, test.code, code, err)
+		}
+	}
+
+	// Errors without an exit code are plain failures.
+	if code := ExitCode(errors.New("failed")); code != ExitFailure {
+		t.Errorf("expected a plain error to exit with %d, got %d", ExitFailure, code)
+	}
+}
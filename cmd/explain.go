@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/nkarpenko/koho-transaction/common/model"
+	"github.com/nkarpenko/koho-transaction/validator"
+	"github.com/spf13/cobra"
+)
+
+// Create the explain command.
+var explainCmd = &cobra.Command{
+	Use:   "explain",
+	Short: "Explain which earlier loads counted toward a transaction's decision.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		// Get the config file.
+		c, err := getConfig(cmd)
+		if err != nil {
+			return err
+		}
+		txid, _ := cmd.Flags().GetInt("id")
+		customerID, _ := cmd.Flags().GetInt("customer")
+
+		// Replay the inputs up to the transaction being explained.
+		tx, err := replayUntil(c, c.Inputs(), func(tx *model.Transaction) bool {
+			return tx.ID == txid && tx.CustomerID == customerID
+		})
+		if err != nil {
+			return inputError(err)
+		}
+		if tx == nil {
+			return fmt.Errorf("transaction %d for customer %d not found in the inputs", txid, customerID)
+		}
+
+		// Explain the decision.
+		exp := validator.New(c).Explain(tx)
+		printDecision(exp.Result)
+		for _, w := range exp.Windows {
+			printWindow(w)
+		}
+		fmt.Println("Rules:")
+		for _, eval := range exp.Evaluations {
+			printEvaluation(eval)
+		}
+		return nil
+	},
+}
+
+func init() {
+	explainCmd.Flags().Int("id", 0, "Transaction id to explain.")
+	explainCmd.Flags().Int("customer", 0, "Customer id of the transaction.")
+	explainCmd.MarkFlagRequired("id")
+	explainCmd.MarkFlagRequired("customer")
+}
+
+// printWindow prints a limit window's boundaries and the earlier transactions
+// counted in it.
+func printWindow(w model.Window) {
+	fmt.Printf("%s window, after %s and before %s: $%.2f loaded in %d loads\n",
+		w.Name, w.Start.Format(time.RFC3339), w.End.Format(time.RFC3339), w.Amount, w.Count)
+
+	for _, e := range w.Entries {
+		var counted string
+		switch {
+		case e.InSum && e.InCount:
+			counted = "amount and count"
+		case e.InSum:
+			counted = "amount"
+		default:
+			counted = "count"
+		}
+		fmt.Printf("\t%d at %s: $%.2f, counted toward %s\n", e.ID, e.Time.Format(time.RFC3339), e.Amount, counted)
+	}
+}
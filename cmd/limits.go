@@ -2,7 +2,12 @@ package cmd
 
 import (
 	"fmt"
+	"sort"
+	"time"
 
+	"github.com/nkarpenko/koho-transaction/common/model"
+	"github.com/nkarpenko/koho-transaction/conf"
+	"github.com/nkarpenko/koho-transaction/validator"
 	"github.com/spf13/cobra"
 )
 
@@ -10,13 +15,17 @@ import (
 var limitsCmd = &cobra.Command{
 	Use:   "limits",
 	Short: "Display user transaction limits.",
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 
 		// Get the config file.
 		c, err := getConfig(cmd)
 		if err != nil {
-			fmt.Printf("error getting config: %+v\n", err)
-			return
+			return err
+		}
+
+		// Print a single customer's usage instead if one was asked for.
+		if cmd.Flags().Changed("customer") {
+			return printUsage(cmd, c)
 		}
 
 		// Print the users transaction limits from config.
@@ -24,6 +33,100 @@ var limitsCmd = &cobra.Command{
 		fmt.Printf("\tMax of $%+v can be loaded per day.\n", c.Limits.DailyAmount)
 		fmt.Printf("\tMax of $%+v can be loaded per week.\n", c.Limits.WeeklyAmount)
 		fmt.Printf("\tMax of %+v loads per day.\n", c.Limits.DailyTransactions)
-		return
+		if c.Limits.WeeklyTransactions > 0 {
+			fmt.Printf("\tMax of %+v loads per week.\n", c.Limits.WeeklyTransactions)
+		}
+		if c.Limits.MonthlyTransactions > 0 {
+			fmt.Printf("\tMax of %+v loads per month.\n", c.Limits.MonthlyTransactions)
+		}
+		if c.Groups != nil {
+			daily, weekly := c.Limits.GroupDailyAmount, c.Limits.GroupWeeklyAmount
+			if daily <= 0 {
+				daily = c.Limits.DailyAmount
+			}
+			if weekly <= 0 {
+				weekly = c.Limits.WeeklyAmount
+			}
+			fmt.Printf("\tMax of $%+v can be loaded per day across a linked-account group.\n", daily)
+			fmt.Printf("\tMax of $%+v can be loaded per week across a linked-account group.\n", weekly)
+		}
+		if c.Limits.ProgramDailyAmount > 0 {
+			fmt.Printf("\tMax of $%+v can be loaded per day across all customers.\n", c.Limits.ProgramDailyAmount)
+		}
+		if c.Limits.ProgramWeeklyAmount > 0 {
+			fmt.Printf("\tMax of $%+v can be loaded per week across all customers.\n", c.Limits.ProgramWeeklyAmount)
+		}
+		if c.Limits.MinLoadAmount > 0 {
+			fmt.Printf("\tMin of $%+v per load.\n", c.Limits.MinLoadAmount)
+		}
+		if c.Limits.MaxLoadAmount > 0 {
+			fmt.Printf("\tMax of $%+v per load.\n", c.Limits.MaxLoadAmount)
+		}
+		if c.Limits.VelocityLoads > 0 && c.Limits.VelocityWindow > 0 {
+			fmt.Printf("\tMax of %+v loads within %v.\n", c.Limits.VelocityLoads, c.Limits.VelocityWindow)
+		}
+		if c.Limits.MinLoadGap > 0 {
+			fmt.Printf("\tMin of %v between loads.\n", c.Limits.MinLoadGap)
+		}
+		if c.Limits.MaxBalance > 0 {
+			fmt.Printf("\tMax balance of $%+v.\n", c.Limits.MaxBalance)
+		}
+
+		// Print the limits overridden by each KYC level.
+		levels := make([]string, 0, len(c.KYCLimits))
+		for level := range c.KYCLimits {
+			levels = append(levels, level)
+		}
+		sort.Strings(levels)
+		for _, level := range levels {
+			l := c.KYCLimits[level].WithDefaults(c.Limits)
+			fmt.Printf("KYC level %s limits:\n", level)
+			fmt.Printf("\tMax of $%+v can be loaded per day.\n", l.DailyAmount)
+			fmt.Printf("\tMax of $%+v can be loaded per week.\n", l.WeeklyAmount)
+			fmt.Printf("\tMax of %+v loads per day.\n", l.DailyTransactions)
+		}
+		return nil
 	},
 }
+
+func init() {
+	limitsCmd.Flags().Int("customer", 0, "Show how much of each limit this customer has used and has left.")
+	limitsCmd.Flags().String("at", "", "Time to show the customer's usage at. Defaults to now.")
+}
+
+// printUsage replays the configured inputs and prints the customer's usage of
+// each window limit.
+func printUsage(cmd *cobra.Command, c *conf.Config) error {
+	customerID, _ := cmd.Flags().GetInt("customer")
+
+	// Parse the time with the same formats as the input.
+	at := time.Now().UTC()
+	if s, _ := cmd.Flags().GetString("at"); s != "" {
+		var err error
+		decoder := &model.Decoder{Layouts: c.TimeLayouts}
+		if at, err = decoder.ParseTime(s); err != nil {
+			return err
+		}
+	}
+
+	// Replay the inputs so the usage includes every earlier load.
+	if err := replayInputs(c, c.Inputs()); err != nil {
+		return inputError(err)
+	}
+
+	usage := validator.New(c).Usage(customerID, at)
+	fmt.Printf("Usage for customer %d at %s:\n", usage.CustomerID, usage.At.Format(time.RFC3339))
+	for _, w := range usage.Windows {
+		fmt.Printf("\t%s: $%.2f loaded", w.Window, w.AmountUsed)
+		if w.AmountLimit > 0 {
+			fmt.Printf(" of $%.2f, $%.2f left", w.AmountLimit, w.AmountRemaining)
+		}
+		fmt.Printf(". %d loads", w.CountUsed)
+		if w.CountLimit > 0 {
+			fmt.Printf(" of %d, %d left", w.CountLimit, w.CountRemaining)
+		}
+		fmt.Println(".")
+	}
+
+	return nil
+}
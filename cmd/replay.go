@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/nkarpenko/koho-transaction/conf"
+	"github.com/nkarpenko/koho-transaction/replay"
+	"github.com/spf13/cobra"
+)
+
+// Create the replay command.
+var replayCmd = &cobra.Command{
+	Use:   "replay",
+	Short: "Compare the decisions of two configs over the same input.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		// Load both configs.
+		pathA, _ := cmd.Flags().GetString("config-a")
+		pathB, _ := cmd.Flags().GetString("config-b")
+		a, err := conf.Load(pathA)
+		if err != nil {
+			return configError(err)
+		}
+		b, err := conf.Load(pathB)
+		if err != nil {
+			return configError(err)
+		}
+
+		// Run both over the same input, the CLI input or else config A's.
+		inputs, _ := cmd.Flags().GetStringArray("input")
+		if len(inputs) == 0 {
+			inputs = a.Inputs()
+		}
+		a.InputFiles, b.InputFiles = inputs, inputs
+
+		resultsA, err := replay.Run(a)
+		if err != nil {
+			return inputError(err)
+		}
+		resultsB, err := replay.Run(b)
+		if err != nil {
+			return inputError(err)
+		}
+
+		printComparison(replay.Compare(resultsA, resultsB))
+		return nil
+	},
+}
+
+func init() {
+	replayCmd.Flags().String("config-a", "", "Current configuration file.")
+	replayCmd.Flags().String("config-b", "", "Proposed configuration file.")
+	replayCmd.MarkFlagRequired("config-a")
+	replayCmd.MarkFlagRequired("config-b")
+}
+
+// printComparison prints the decision counts, flips and dollar impact of two
+// runs.
+func printComparison(cmp *replay.Comparison) {
+
+	// Print the decision counts by reason, accepted first.
+	reasons := []string{replay.Accepted}
+	for reason := range cmp.ReasonsA {
+		if reason != replay.Accepted {
+			reasons = append(reasons, reason)
+		}
+	}
+	for reason := range cmp.ReasonsB {
+		if _, ok := cmp.ReasonsA[reason]; !ok && reason != replay.Accepted {
+			reasons = append(reasons, reason)
+		}
+	}
+	sort.Strings(reasons[1:])
+
+	fmt.Println("Decisions by reason (a -> b):")
+	for _, reason := range reasons {
+		fmt.Printf("\t%s: %d -> %d\n", reason, cmp.ReasonsA[reason], cmp.ReasonsB[reason])
+	}
+
+	// Print every flipped transaction.
+	fmt.Printf("Flipped decisions: %d\n", len(cmp.Flipped))
+	for _, flip := range cmp.Flipped {
+		fmt.Printf("\t%d for customer %d, $%.2f: %s -> %s\n",
+			flip.Key.ID, flip.Key.CustomerID, flip.A.LoadAmount, decision(flip.A.Accepted, flip.A.Reason), decision(flip.B.Accepted, flip.B.Reason))
+	}
+
+	// Print the dollar impact.
+	fmt.Println("Dollar impact:")
+	fmt.Printf("\taccepted: $%.2f -> $%.2f\n", cmp.AcceptedA, cmp.AcceptedB)
+	fmt.Printf("\tnewly accepted: $%.2f\n", cmp.Gained)
+	fmt.Printf("\tnewly rejected: $%.2f\n", cmp.Lost)
+	fmt.Printf("\tnet change: $%.2f\n", cmp.Net())
+}
+
+// decision describes an accept or reject decision.
+func decision(accepted bool, reason string) string {
+	if accepted {
+		return replay.Accepted
+	}
+
+	return "rejected (" + reason + ")"
+}
@@ -2,6 +2,7 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/nkarpenko/koho-transaction/app"
@@ -15,42 +16,69 @@ func RootCmd() *cobra.Command {
 	var rootCmd = &cobra.Command{
 		Use:   "koho-transaction",
 		Short: "Koho transaction validation tool.",
-		Run: func(cmd *cobra.Command, args []string) {
+		RunE: func(cmd *cobra.Command, args []string) error {
 
 			// Get the config file.
 			c, err := getConfig(cmd)
 			if err != nil {
-				fmt.Printf("error getting config: %+v\n", err)
-				return
+				return err
 			}
 
 			// Start the app.
-			start(c)
+			return start(c)
 		},
+
+		// Errors are printed once by main along with the exit code.
+		SilenceUsage:  true,
+		SilenceErrors: true,
 	}
 
 	// Add any additional flags.
 	rootCmd.PersistentFlags().StringP("config", "c", "config.yml", "Specify local configuration file.")
+	rootCmd.PersistentFlags().StringArrayP("input", "i", nil, "Input file, glob pattern or - for stdin. Can be repeated.")
+	rootCmd.PersistentFlags().BoolP("verbose", "v", false, "Add the reason code and limit usage to each result.")
+	rootCmd.PersistentFlags().StringP("output", "o", "", "Output file or - for stdout.")
+	rootCmd.PersistentFlags().Int("daily-amount", 0, "Override the daily load amount limit.")
+	rootCmd.PersistentFlags().Int("weekly-amount", 0, "Override the weekly load amount limit.")
+	rootCmd.PersistentFlags().Int("daily-transactions", 0, "Override the daily load count limit.")
+	rootCmd.PersistentFlags().StringArray(conf.SetFlag, nil, "Override any config value as key=value, for example limits.max_balance=3000. Can be repeated.")
 
 	// Add additional commands.
 	rootCmd.AddCommand(versionCmd)
 	rootCmd.AddCommand(limitsCmd)
+	rootCmd.AddCommand(checkCmd)
+	rootCmd.AddCommand(configCmd)
+	rootCmd.AddCommand(diffCmd)
+	rootCmd.AddCommand(explainCmd)
+	rootCmd.AddCommand(replayCmd)
+	rootCmd.AddCommand(serveCmd)
 
 	return rootCmd
 }
 
-// Start the application.
-func start(c *conf.Config) {
+// Start the application. Runs that skip invalid input records end in a
+// partial failure.
+func start(c *conf.Config) error {
 
 	// Init the app.
 	a, err := app.New(c)
 	if err != nil {
-		fmt.Printf("App failed to start. Error: %v\n", err)
-		return
+		return configError(err)
 	}
 
 	// Start the app.
-	a.Start()
+	summary, err := a.Start()
+	var inputErr *app.InputError
+	switch {
+	case errors.As(err, &inputErr):
+		return inputError(err)
+	case err != nil:
+		return err
+	case summary.ParseErrors > 0:
+		return &ExitError{Code: ExitPartialFailure, Err: fmt.Errorf("%d invalid input records were skipped", summary.ParseErrors)}
+	}
+
+	return nil
 }
 
 func getConfig(cmd *cobra.Command) (*conf.Config, error) {
@@ -58,15 +86,19 @@ func getConfig(cmd *cobra.Command) (*conf.Config, error) {
 	// Get the config file.
 	configFile, err := cmd.Flags().GetString("config")
 	if err != nil {
-		fmt.Printf("invalid CLI flags, please use the -h flag to see all available options: %+v\n", err)
-		return &conf.Config{}, err
+		return &conf.Config{}, fmt.Errorf("invalid CLI flags, please use the -h flag to see all available options: %w", err)
+	}
+
+	// Without a config flag the default file is optional, so the environment
+	// and CLI flags alone can make up the config.
+	if !cmd.Flags().Changed("config") {
+		configFile = ""
 	}
 
-	// Load the config file.
-	config, err := conf.Load(configFile)
+	// Load the config file, overridden by the environment and CLI flags.
+	config, err := conf.LoadFlags(configFile, cmd.Flags())
 	if err != nil {
-		fmt.Printf("failed to load configration file: %+v\n", err)
-		return &conf.Config{}, err
+		return &conf.Config{}, configError(err)
 	}
 
 	// Successful config request.
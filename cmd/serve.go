@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/nkarpenko/koho-transaction/server"
+	"github.com/spf13/cobra"
+)
+
+// Create the serve command.
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve customer limit usage over HTTP.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+
+		// Get the config file.
+		c, err := getConfig(cmd)
+		if err != nil {
+			return err
+		}
+
+		// Replay the configured inputs so queries see every earlier load.
+		if err := replayInputs(c, c.Inputs()); err != nil {
+			return inputError(err)
+		}
+
+		// Serve requests until the server fails.
+		addr, _ := cmd.Flags().GetString("addr")
+		fmt.Printf("Serving on %s\n", addr)
+		if err := server.New(c).ListenAndServe(addr); err != nil {
+			return fmt.Errorf("server failed: %w", err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	serveCmd.Flags().String("addr", ":8080", "Address to listen on.")
+}
@@ -10,16 +10,15 @@ import (
 var versionCmd = &cobra.Command{
 	Use:   "version",
 	Short: "Display app version.",
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 
 		// Get the config file.
 		c, err := getConfig(cmd)
 		if err != nil {
-			fmt.Printf("error getting config: %+v\n", err)
-			return
+			return err
 		}
 
 		fmt.Println("Koho user transaction tool v" + c.Version)
-		return
+		return nil
 	},
 }
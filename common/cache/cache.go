@@ -3,10 +3,106 @@
 // interacting with a memory store cache such as Redis.
 package cache
 
-import "github.com/nkarpenko/koho-transaction/common/model"
+import (
+	"time"
+
+	"github.com/nkarpenko/koho-transaction/common/model"
+)
 
 // Cache var is the main cache variable used for storing transaction data. In
 // a real production scenario, we would use some memory store caching mechanism
 // such as Redis/Memcache or nosql/sql solution. Please review root directory
 // README.md file for more details.
-var Cache = &map[int][]model.Result{}
+var Cache = New()
+
+// Store type holds each customer's processed results, newest first, along
+// with running totals of the loads made across the program each day.
+type Store struct {
+	Customers map[int][]model.Result
+	Days      map[string]*Day
+}
+
+// Day type holds the running totals of a single day across all customers.
+// Holds are kept by reference since they stop counting once they expire.
+type Day struct {
+	Settled float64
+	Holds   []Ref
+}
+
+// Ref type points to a stored result of a customer.
+type Ref struct {
+	CustomerID int
+	ID         int
+}
+
+// New returns an empty store, isolated from the main cache.
+func New() *Store {
+	return &Store{
+		Customers: map[int][]model.Result{},
+		Days:      map[string]*Day{},
+	}
+}
+
+// Day returns the running totals of the day t falls on, or nil when nothing
+// was recorded that day.
+func (s *Store) Day(t time.Time) *Day {
+	return s.Days[dayKey(t)]
+}
+
+// Settle adds amount to the settled total of the day t falls on. Negative
+// amounts take reversed funds back off.
+func (s *Store) Settle(t time.Time, amount float64) {
+	s.day(t).Settled += amount
+}
+
+// Hold records a pending authorization on the day it was made.
+func (s *Store) Hold(res *model.Result) {
+	d := s.day(res.Time)
+	d.Holds = append(d.Holds, Ref{CustomerID: res.CustomerID, ID: res.ID})
+}
+
+// Unhold removes a captured or voided authorization from its day.
+func (s *Store) Unhold(res *model.Result) {
+	d := s.Day(res.Time)
+	if d == nil {
+		return
+	}
+
+	for i, ref := range d.Holds {
+		if ref.CustomerID == res.CustomerID && ref.ID == res.ID {
+			d.Holds = append(d.Holds[:i], d.Holds[i+1:]...)
+			return
+		}
+	}
+}
+
+// Result returns the stored result a reference points to, or nil if there is
+// none.
+func (s *Store) Result(ref Ref) *model.Result {
+	entries := s.Customers[ref.CustomerID]
+	for i := range entries {
+		if entries[i].ID == ref.ID && entries[i].IsLoad() {
+			return &entries[i]
+		}
+	}
+
+	return nil
+}
+
+// day returns the running totals of the day t falls on, adding them the first
+// time the day is seen.
+func (s *Store) day(t time.Time) *Day {
+	key := dayKey(t)
+	d, ok := s.Days[key]
+	if !ok {
+		d = &Day{}
+		s.Days[key] = d
+	}
+
+	return d
+}
+
+// dayKey returns the calendar date of t in its own location.
+func dayKey(t time.Time) string {
+	return t.Format("2006-01-02")
+}
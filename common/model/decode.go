@@ -0,0 +1,345 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// transactionFields lists every field a transaction record may contain.
+var transactionFields = []string{"id", "customer_id", "load_amount", "time", "type", "reference_id", "currency"}
+
+// FieldError describes a single schema violation on a transaction field.
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+// Error implements the error interface.
+func (e FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// SchemaError holds every field level violation found on a single record.
+type SchemaError []FieldError
+
+// Error implements the error interface.
+func (e SchemaError) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fe.Error()
+	}
+
+	return strings.Join(msgs, "; ")
+}
+
+// epochMillisThreshold separates epoch seconds from epoch milliseconds. Any
+// epoch value at or above it (year 5138 in seconds, 1973 in milliseconds) is
+// read as milliseconds.
+const epochMillisThreshold = 1e11
+
+// Decoder converts raw JSON records into transactions.
+type Decoder struct {
+	// Strict rejects records with missing, ill-typed or unknown fields and
+	// non-positive amounts instead of leaving fields at their zero value.
+	Strict bool
+
+	// Layouts holds extra time layouts tried after RFC3339 and epoch times.
+	Layouts []string
+}
+
+// Decode parses a single JSON record into a transaction. In strict mode every
+// violation is returned together as a SchemaError.
+func (d *Decoder) Decode(b []byte) (*Transaction, error) {
+	var (
+		t    = &Transaction{}
+		errs SchemaError
+		v    map[string]interface{}
+	)
+
+	// Unmarshal transaction into map first before manual type conversion.
+	// Numbers are kept as json.Number so epoch times keep their precision.
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	// Report any field the schema doesn't know about.
+	if d.Strict {
+		errs = append(errs, unknownFields(v)...)
+	}
+
+	// Convert id string to int.
+	if id, ok := d.stringField(v, "id", &errs); ok {
+		n, err := strconv.Atoi(id)
+		if err != nil {
+			errs = append(errs, FieldError{"id", "must be an integer string"})
+		}
+		t.ID = n
+	}
+
+	// Convert customer id string to int.
+	if cid, ok := d.stringField(v, "customer_id", &errs); ok {
+		n, err := strconv.Atoi(cid)
+		if err != nil {
+			errs = append(errs, FieldError{"customer_id", "must be an integer string"})
+		}
+		t.CustomerID = n
+	}
+
+	// Read the transaction type, defaulting to a load.
+	t.Type = TypeLoad
+	if typ, ok := v["type"].(string); ok {
+		switch typ {
+		case TypeLoad, TypeReversal, TypeAuthorization, TypeCapture, TypeVoid, TypeSpend:
+			t.Type = typ
+		default:
+			errs = append(errs, FieldError{"type", fmt.Sprintf("unknown transaction type %q", typ)})
+		}
+	} else if raw, ok := v["type"]; ok && raw != nil && d.Strict {
+		errs = append(errs, FieldError{"type", fmt.Sprintf("must be a string, got %s", jsonType(raw))})
+	}
+
+	// Convert the reference id of a reversal, capture or void to int.
+	if t.Type == TypeReversal || t.Type == TypeCapture || t.Type == TypeVoid {
+		if ref, ok := d.stringField(v, "reference_id", &errs); ok {
+			n, err := strconv.Atoi(ref)
+			if err != nil {
+				errs = append(errs, FieldError{"reference_id", "must be an integer string"})
+			}
+			t.ReferenceID = n
+		}
+	}
+
+	// Convert the load amount money string or number to float64. Voids
+	// release the whole hold so their amount is optional.
+	if raw, ok := v["load_amount"]; !ok || raw == nil {
+		if d.Strict && t.Type != TypeVoid {
+			errs = append(errs, FieldError{"load_amount", "is required"})
+		}
+	} else {
+		switch raw.(type) {
+		case string, json.Number:
+			n, currency, err := ParseMoney(raw)
+			t.Currency = currency
+			switch {
+			case err != nil:
+				errs = append(errs, FieldError{"load_amount", err.Error()})
+			case d.Strict && n <= 0:
+				errs = append(errs, FieldError{"load_amount", "must be greater than zero"})
+			}
+			t.LoadAmount = n
+		default:
+			if d.Strict {
+				errs = append(errs, FieldError{"load_amount", fmt.Sprintf("must be a string or number, got %s", jsonType(raw))})
+			}
+		}
+	}
+
+	// Read the currency code, which must agree with any code in the amount.
+	if raw, ok := v["currency"]; ok && raw != nil {
+		code, ok := raw.(string)
+		code = strings.ToUpper(strings.TrimSpace(code))
+		switch {
+		case !ok:
+			if d.Strict {
+				errs = append(errs, FieldError{"currency", fmt.Sprintf("must be a string, got %s", jsonType(raw))})
+			}
+		case !IsCurrencyCode(code):
+			errs = append(errs, FieldError{"currency", fmt.Sprintf("invalid currency code %q", code)})
+		case t.Currency != "" && t.Currency != code:
+			errs = append(errs, FieldError{"currency", fmt.Sprintf("does not match load_amount currency %s", t.Currency)})
+		default:
+			t.Currency = code
+		}
+	}
+
+	// Convert time string or epoch number to time.Time.
+	if raw, ok := d.field(v, "time", &errs); ok {
+		switch raw.(type) {
+		case string, json.Number:
+			ts, err := d.parseTime(raw)
+			if err != nil {
+				errs = append(errs, FieldError{"time", err.Error()})
+			}
+			t.Time = ts
+		default:
+			if d.Strict {
+				errs = append(errs, FieldError{"time", fmt.Sprintf("must be a string or number, got %s", jsonType(raw))})
+			}
+		}
+	}
+
+	// Outside of strict mode only conversion failures are errors.
+	if len(errs) > 0 {
+		if !d.Strict {
+			return nil, errs[0]
+		}
+		return nil, errs
+	}
+
+	return t, nil
+}
+
+// ParseTime converts a timestamp string in any of the supported formats into
+// a UTC time.
+func (d *Decoder) ParseTime(s string) (time.Time, error) {
+	return d.parseTime(s)
+}
+
+// parseTime converts a string or numeric timestamp into a UTC time. RFC3339
+// (with offsets and fractional seconds) is tried first, then any extra
+// configured layouts, then Unix epoch seconds or milliseconds. JSON numbers
+// are always epoch times.
+func (d *Decoder) parseTime(raw interface{}) (time.Time, error) {
+
+	// JSON numbers can only be epoch times.
+	if n, ok := raw.(json.Number); ok {
+		return parseEpoch(n.String())
+	}
+	s, _ := raw.(string)
+	s = strings.TrimSpace(s)
+
+	// RFC3339Nano also accepts RFC3339 times without fractional seconds.
+	if ts, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return ts.UTC(), nil
+	}
+
+	// Try the extra layouts in the configured order, before the epoch
+	// fallback so all-digit layouts such as 20060102 can match.
+	for _, layout := range d.Layouts {
+		if ts, err := time.Parse(layout, s); err == nil {
+			return ts.UTC(), nil
+		}
+	}
+
+	// Other numeric strings are epoch times.
+	if ts, err := parseEpoch(s); err == nil {
+		return ts, nil
+	}
+
+	return time.Time{}, fmt.Errorf("unsupported time format %q", s)
+}
+
+// parseEpoch converts Unix epoch seconds or milliseconds, with optional
+// fractional digits, into a UTC time.
+func parseEpoch(s string) (time.Time, error) {
+	invalid := fmt.Errorf("invalid epoch time %q", s)
+
+	// Split the whole and fractional parts so no precision is lost.
+	whole, frac := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		whole, frac = s[:i], s[i+1:]
+	}
+	n, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || strings.HasPrefix(whole, "+") {
+		return time.Time{}, invalid
+	}
+
+	// Read up to nine fractional digits as a fraction of one unit.
+	var fracNanos int64
+	if frac != "" {
+		if len(frac) > 9 {
+			frac = frac[:9]
+		}
+		fracNanos, err = strconv.ParseInt(frac+strings.Repeat("0", 9-len(frac)), 10, 64)
+		if err != nil || fracNanos < 0 {
+			return time.Time{}, invalid
+		}
+		if strings.HasPrefix(whole, "-") {
+			fracNanos = -fracNanos
+		}
+	}
+
+	// Large values are milliseconds, anything else is seconds.
+	if n >= epochMillisThreshold || n <= -epochMillisThreshold {
+		return time.Unix(n/1000, (n%1000)*int64(time.Millisecond)+fracNanos/1000).UTC(), nil
+	}
+
+	return time.Unix(n, fracNanos).UTC(), nil
+}
+
+// field looks up a value in the record. Missing values are only reported in
+// strict mode.
+func (d *Decoder) field(v map[string]interface{}, key string, errs *SchemaError) (interface{}, bool) {
+	raw, ok := v[key]
+	if !ok || raw == nil {
+		if d.Strict {
+			*errs = append(*errs, FieldError{key, "is required"})
+		}
+		return nil, false
+	}
+
+	return raw, true
+}
+
+// stringField looks up a string value in the record. Missing and ill-typed
+// values are only reported in strict mode.
+func (d *Decoder) stringField(v map[string]interface{}, key string, errs *SchemaError) (string, bool) {
+	raw, ok := d.field(v, key, errs)
+	if !ok {
+		return "", false
+	}
+
+	s, ok := raw.(string)
+	if !ok {
+		if d.Strict {
+			*errs = append(*errs, FieldError{key, fmt.Sprintf("must be a string, got %s", jsonType(raw))})
+		}
+		return "", false
+	}
+
+	return s, true
+}
+
+// unknownFields returns an error for every key not defined by the schema.
+func unknownFields(v map[string]interface{}) SchemaError {
+	var errs SchemaError
+
+	// Collect the unknown keys in a stable order.
+	var keys []string
+	for key := range v {
+		if !knownField(key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		errs = append(errs, FieldError{key, "unknown field"})
+	}
+
+	return errs
+}
+
+// knownField reports whether key is part of the transaction schema.
+func knownField(key string) bool {
+	for _, field := range transactionFields {
+		if field == key {
+			return true
+		}
+	}
+
+	return false
+}
+
+// jsonType returns the JSON type name of a decoded value.
+func jsonType(v interface{}) string {
+	switch v.(type) {
+	case bool:
+		return "boolean"
+	case float64, json.Number:
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+
+	return "string"
+}
@@ -0,0 +1,168 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+type test struct {
+	result bool
+	strict bool
+	input  string
+	fields []string
+}
+
+func TestDecode(t *testing.T) {
+
+	// Initialize test cases.
+	tests := []test{
+		{
+			result: true,
+			strict: true,
+			input:  `{"id":"1","customer_id":"2","load_amount":"$3.50","time":"2000-01-01T00:00:00Z"}`,
+		},
+		{
+			result: false,
+			strict: true,
+			input:  `{"id":1,"customer_id":"2","time":"2000-01-01T00:00:00Z","memo":"x"}`,
+			fields: []string{"memo", "id", "load_amount"},
+		},
+		{
+			result: false,
+			strict: true,
+			input:  `{"id":"1","customer_id":"2","load_amount":"$-3.50","time":"2000-01-01"}`,
+			fields: []string{"load_amount", "time"},
+		},
+		{
+			result: true,
+			strict: true,
+			input:  `{"id":"2","customer_id":"2","load_amount":"$3.50","time":"2000-01-01T00:00:00Z","type":"reversal","reference_id":"1"}`,
+		},
+		{
+			result: false,
+			strict: true,
+			input:  `{"id":"2","customer_id":"2","load_amount":"$3.50","time":"2000-01-01T00:00:00Z","type":"reversal"}`,
+			fields: []string{"reference_id"},
+		},
+		{
+			result: false,
+			strict: false,
+			input:  `{"id":"2","customer_id":"2","load_amount":"$3.50","time":"2000-01-01T00:00:00Z","type":"refund"}`,
+		},
+		{
+			result: true,
+			strict: true,
+			input:  `{"id":"2","customer_id":"2","load_amount":"$3.50","time":"2000-01-01T00:00:00Z","type":"spend"}`,
+		},
+		{
+			result: true,
+			strict: true,
+			input:  `{"id":"3","customer_id":"2","load_amount":"3.50","currency":"usd","time":"2000-01-01T00:00:00Z"}`,
+		},
+		{
+			result: false,
+			strict: true,
+			input:  `{"id":"3","customer_id":"2","load_amount":"EUR 3.50","currency":"USD","time":"2000-01-01T00:00:00Z"}`,
+			fields: []string{"currency"},
+		},
+		{
+			result: true,
+			strict: false,
+			input:  `{"id":1,"customer_id":"2","time":"2000-01-01T00:00:00Z","memo":"x"}`,
+		},
+		{
+			result: false,
+			strict: false,
+			input:  `{"id":"1","customer_id":"2","load_amount":"","time":"2000-01-01T00:00:00Z"}`,
+		},
+	}
+
+	// Run test cases.
+	for i, test := range tests {
+		d := &Decoder{Strict: test.strict}
+		_, err := d.Decode([]byte(test.input))
+		if test.result != (err == nil) {
+			t.Errorf("test case %d expected success '%+v', got error '%+v'", i, test.result, err)
+		}
+
+		// Confirm every violated field was reported.
+		if len(test.fields) == 0 {
+			continue
+		}
+		errs, ok := err.(SchemaError)
+		if !ok {
+			t.Errorf("test case %d expected a schema error, got '%+v'", i, err)
+			continue
+		}
+		if len(errs) != len(test.fields) {
+			t.Errorf("test case %d expected %d field errors, got '%+v'", i, len(test.fields), errs)
+			continue
+		}
+		for j, field := range test.fields {
+			if errs[j].Field != field {
+				t.Errorf("test case %d expected error on field '%s', got '%s'", i, field, errs[j].Field)
+			}
+		}
+	}
+}
+
+func TestParseTime(t *testing.T) {
+	want := time.Date(2000, 1, 1, 5, 0, 0, 0, time.UTC)
+
+	// Initialize test cases, all describing the same instant.
+	tests := []interface{}{
+		"2000-01-01T05:00:00Z",
+		"2000-01-01T00:00:00-05:00",
+		"2000-01-01T05:00:00.000Z",
+		"946702800",
+		"946702800000",
+		json.Number("946702800"),
+		json.Number("946702800000"),
+		"01/01/2000 05:00",
+	}
+
+	// Run test cases.
+	d := &Decoder{Layouts: []string{"01/02/2006 15:04"}}
+	for _, test := range tests {
+		ts, err := d.parseTime(test)
+		if err != nil {
+			t.Errorf("unable to parse time '%v': %+v", test, err)
+			continue
+		}
+		if !ts.Equal(want) || ts.Location() != time.UTC {
+			t.Errorf("time '%v' parsed as '%v', expected '%v'", test, ts, want)
+		}
+	}
+
+	// Fractional seconds and milliseconds are kept.
+	ts, err := d.parseTime("946702800.25")
+	if err != nil || !ts.Equal(want.Add(250*time.Millisecond)) {
+		t.Errorf("fractional epoch parsed as '%v': %+v", ts, err)
+	}
+	ts, err = d.parseTime("2000-01-01T05:00:00.123456789Z")
+	if err != nil || ts.Nanosecond() != 123456789 {
+		t.Errorf("RFC3339Nano time parsed as '%v': %+v", ts, err)
+	}
+
+	// All-digit layouts are tried before numeric strings fall back to epoch
+	// times, which JSON numbers always are.
+	d = &Decoder{Layouts: []string{"20060102"}}
+	ts, err = d.parseTime("20000103")
+	if err != nil || !ts.Equal(time.Date(2000, 1, 3, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("numeric layout time parsed as '%v': %+v", ts, err)
+	}
+	ts, err = d.parseTime("946702800")
+	if err != nil || !ts.Equal(want) {
+		t.Errorf("epoch time with a numeric layout parsed as '%v': %+v", ts, err)
+	}
+	ts, err = d.parseTime(json.Number("20000103"))
+	if err != nil || !ts.Equal(time.Unix(20000103, 0)) {
+		t.Errorf("epoch number with a numeric layout parsed as '%v': %+v", ts, err)
+	}
+
+	// Unknown layouts are rejected.
+	if _, err := d.parseTime("Jan 1 2000"); err == nil {
+		t.Error("expected unknown time layout to fail")
+	}
+}
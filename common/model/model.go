@@ -3,16 +3,88 @@ package model
 
 import (
 	"encoding/json"
+	"math"
+	"reflect"
 	"strconv"
 	"time"
 )
 
+// Transaction types.
+const (
+	// TypeLoad adds funds to a customer's account.
+	TypeLoad = "load"
+
+	// TypeReversal reverses all or part of an earlier accepted load, such as
+	// a chargeback or failed funding, releasing the limit it used.
+	TypeReversal = "reversal"
+
+	// TypeAuthorization places a hold that reserves limit capacity until it
+	// is captured, voided or expires.
+	TypeAuthorization = "authorization"
+
+	// TypeCapture settles all or part of a pending authorization.
+	TypeCapture = "capture"
+
+	// TypeVoid cancels a pending authorization, releasing its hold.
+	TypeVoid = "void"
+
+	// TypeSpend takes funds out of a customer's account, lowering their
+	// balance without releasing any limit.
+	TypeSpend = "spend"
+)
+
+// Reason codes describing why a transaction was rejected or ignored.
+const (
+	ReasonDuplicateID       = "duplicate_id"
+	ReasonFXRateUnavailable = "fx_rate_unavailable"
+	ReasonInvalidReversal   = "invalid_reversal"
+	ReasonInvalidCapture    = "invalid_capture"
+	ReasonInvalidVoid       = "invalid_void"
+	ReasonInvalidAmount     = "invalid_amount"
+	ReasonMinLoadAmount     = "min_load_amount"
+	ReasonMaxLoadAmount     = "max_load_amount"
+	ReasonDailyLoadLimit    = "daily_load_limit"
+	ReasonWeeklyLoadLimit   = "weekly_load_limit"
+	ReasonMonthlyLoadLimit  = "monthly_load_limit"
+	ReasonMinLoadGap        = "min_load_gap"
+	ReasonVelocityLimit     = "velocity_limit"
+	ReasonDailyAmountLimit  = "daily_amount_limit"
+	ReasonWeeklyAmountLimit = "weekly_amount_limit"
+
+	// Linked-account group caps shared by every customer in a group.
+	ReasonGroupDailyAmountLimit  = "group_daily_amount_limit"
+	ReasonGroupWeeklyAmountLimit = "group_weekly_amount_limit"
+
+	// Program-wide liquidity caps across all customers.
+	ReasonProgramDailyAmountLimit  = "program_daily_amount_limit"
+	ReasonProgramWeeklyAmountLimit = "program_weekly_amount_limit"
+
+	// Customer registry account status checks.
+	ReasonUnknownCustomer = "unknown_customer"
+	ReasonAccountFrozen   = "account_frozen"
+	ReasonAccountClosed   = "account_closed"
+
+	// Account balance ceiling and spends beyond the balance.
+	ReasonMaxBalance          = "max_balance"
+	ReasonInsufficientBalance = "insufficient_balance"
+)
+
+// DefaultCurrency is the currency limits are set in when none is configured.
+const DefaultCurrency = "CAD"
+
+// DefaultHoldExpiry is how long an authorization hold stays pending before it
+// expires when no expiry is configured.
+const DefaultHoldExpiry = 7 * 24 * time.Hour
+
 // Transaction struct contains a user's single transaction request.
 type Transaction struct {
-	ID         int       `json:"id"`
-	CustomerID int       `json:"customer_id"`
-	LoadAmount float64   `json:"load_amount"`
-	Time       time.Time `json:"time"`
+	ID          int       `json:"id"`
+	CustomerID  int       `json:"customer_id"`
+	LoadAmount  float64   `json:"load_amount"`
+	Time        time.Time `json:"time"`
+	Type        string    `json:"type"`
+	ReferenceID int       `json:"reference_id"`
+	Currency    string    `json:"currency"`
 }
 
 // Result struct holds the validation results and transaction data to process.
@@ -21,11 +93,33 @@ type Result struct {
 	CustomerID int    `json:"customer_id"`
 	Accepted   bool   `json:"accepted"`
 	Message    string `json:"-"` // enable json field for debugging
+	Reason     string `json:"-"` // reason code of a rejected transaction
 
 	// Don't print these but keep them for cache purposes.
-	LoadAmount    float64   `json:"-"`
-	Time          time.Time `json:"-"`
-	IgnoreMessage bool      `json:"-"`
+	LoadAmount     float64   `json:"-"`
+	Time           time.Time `json:"-"`
+	IgnoreMessage  bool      `json:"-"`
+	Type           string    `json:"-"`
+	ReferenceID    int       `json:"-"`
+	ReversedAmount float64   `json:"-"`
+	Pending        bool      `json:"-"`
+	Voided         bool      `json:"-"`
+	ExpiresAt      time.Time `json:"-"`
+
+	// LoadAmount above is in the limit currency. These hold the amount as
+	// received and the exchange rate used to convert it.
+	Currency       string  `json:"-"`
+	OriginalAmount float64 `json:"-"`
+	FXRate         float64 `json:"-"`
+
+	// Limit usage before the transaction, captured at decision time. It is
+	// only output along with the reason code when Verbose is set.
+	Verbose     bool    `json:"-"`
+	DailyUsed   float64 `json:"-"`
+	DailyLimit  float64 `json:"-"`
+	WeeklyUsed  float64 `json:"-"`
+	WeeklyLimit float64 `json:"-"`
+	DailyCount  int     `json:"-"`
 }
 
 // Output struct contains the vars and converted types for the final application output.
@@ -37,72 +131,225 @@ type Output struct {
 	IgnoreMessage bool   `json:"-"`
 }
 
-// Limits struct holds details on user transaction limits.
+// VerboseOutput struct adds the reason code and limit usage snapshot to the
+// final application output.
+type VerboseOutput struct {
+	Output
+	Reason      string  `json:"reason,omitempty"`
+	DailyUsed   float64 `json:"daily_used"`
+	DailyLimit  float64 `json:"daily_limit"`
+	WeeklyUsed  float64 `json:"weekly_used"`
+	WeeklyLimit float64 `json:"weekly_limit"`
+	DailyCount  int     `json:"daily_count"`
+}
+
+// Evaluation struct holds the outcome of a single load rule. Window limit
+// rules also report how much of the limit was used before the load. A zero
+// limit means the rule has no limit or is disabled.
+type Evaluation struct {
+	Rule      string  // reason code the rule rejects with
+	Passed    bool    // whether the load passed the rule
+	Used      float64 // amount or count used in the rule's window
+	Limit     float64 // amount or count allowed in the rule's window
+	Remaining float64 // largest amount or count the rule would still accept
+	Count     bool    // whether Used and Limit are load counts
+}
+
+// Explanation struct holds how a transaction's decision was reached: the
+// outcome of every rule and the window sums the limits were checked against.
+type Explanation struct {
+	Result      *Result
+	Evaluations []Evaluation
+	Windows     []Window
+}
+
+// Window struct holds a single limit window and the earlier transactions
+// counted in it. Transactions after Start and before End are in the window.
+type Window struct {
+	Name    string
+	Start   time.Time
+	End     time.Time
+	Amount  float64
+	Count   int
+	Entries []WindowEntry
+}
+
+// WindowEntry struct holds an earlier transaction in a window and whether it
+// counted toward the window's amount, its load count or both.
+type WindowEntry struct {
+	ID      int
+	Time    time.Time
+	Type    string
+	Amount  float64
+	InSum   bool
+	InCount bool
+}
+
+// Usage struct holds how much of each window limit a customer has used at a
+// point in time.
+type Usage struct {
+	CustomerID int           `json:"customer_id"`
+	At         time.Time     `json:"at"`
+	Windows    []WindowUsage `json:"windows"`
+}
+
+// WindowUsage struct holds the load amount and count used in a single limit
+// window and what is left of each limit. A limit of zero is disabled, while a
+// zero remaining amount or count under a set limit means it is used up.
+type WindowUsage struct {
+	Window          string  `json:"window"`
+	AmountUsed      float64 `json:"amount_used"`
+	AmountLimit     float64 `json:"amount_limit"`
+	AmountRemaining float64 `json:"amount_remaining"`
+	CountUsed       int     `json:"count_used"`
+	CountLimit      int     `json:"count_limit"`
+	CountRemaining  int     `json:"count_remaining"`
+}
+
+// AmountRemaining returns the largest load that still fits under an amount
+// limit. Window amount limits reject a total equal to the limit, so that is
+// one cent less than the difference, and never less than zero.
+func AmountRemaining(limit, used float64) float64 {
+	remaining := math.Round((limit-used)*100) - 1
+	if remaining < 0 {
+		return 0
+	}
+
+	return remaining / 100
+}
+
+// Limits struct holds details on user transaction limits. Weekly and monthly
+// counts, program-wide amounts, per-transaction amounts, velocity limits and
+// the balance ceiling are disabled when left at zero.
 type Limits struct {
 	DailyAmount       int `mapstructure:"daily_amount"`
 	DailyTransactions int `mapstructure:"daily_transactions"`
 	WeeklyAmount      int `mapstructure:"weekly_amount"`
-}
 
-// UnmarshalJSON implements a custom scanner for the transaction type.
-func (t *Transaction) UnmarshalJSON(b []byte) error {
+	// Longer window count limits.
+	WeeklyTransactions  int `mapstructure:"weekly_transactions"`
+	MonthlyTransactions int `mapstructure:"monthly_transactions"`
 
-	var (
-		err error
-		v   map[string]interface{}
-	)
+	// Amount limits shared by linked customers in a group. The customer
+	// daily and weekly amounts are used when left at zero.
+	GroupDailyAmount  int `mapstructure:"group_daily_amount"`
+	GroupWeeklyAmount int `mapstructure:"group_weekly_amount"`
 
-	// Unmarshal transaction into map first before manual type conversion.
-	if err := json.Unmarshal(b, &v); err != nil {
-		return err
-	}
+	// Program-wide amount limits across all customers.
+	ProgramDailyAmount  int `mapstructure:"program_daily_amount"`
+	ProgramWeeklyAmount int `mapstructure:"program_weekly_amount"`
 
-	// Convert id string to int.
-	if id, ok := v["id"].(string); ok {
+	// Per-transaction amount limits.
+	MinLoadAmount float64 `mapstructure:"min_load_amount"`
+	MaxLoadAmount float64 `mapstructure:"max_load_amount"`
 
-		// Set transaction ID to integer value.
-		t.ID, err = strconv.Atoi(id)
-		if err != nil {
-			return err
-		}
+	// Short window velocity limits.
+	VelocityLoads  int           `mapstructure:"velocity_loads"`
+	VelocityWindow time.Duration `mapstructure:"velocity_window"`
+	MinLoadGap     time.Duration `mapstructure:"min_load_gap"`
+
+	// Account balance ceiling.
+	MaxBalance float64 `mapstructure:"max_balance"`
+
+	// Explicit holds the keys of the limits that were given explicitly, so a
+	// zero limit can disable a default rather than fall back to it. When nil,
+	// every limit left at zero counts as unset.
+	Explicit map[string]bool `mapstructure:"-"`
+}
+
+// WithDefaults returns a copy of the limits with every unset limit taken from
+// defaults instead. Limits listed in Explicit are set, even at zero.
+func (l Limits) WithDefaults(defaults *Limits) *Limits {
+	if defaults == nil {
+		return &l
 	}
 
-	// Convert customer id string to int.
-	if cid, ok := v["customer_id"].(string); ok {
+	// Copy any unset limit over from the defaults.
+	out := reflect.ValueOf(&l).Elem()
+	def := reflect.ValueOf(defaults).Elem()
+	for i := 0; i < out.NumField(); i++ {
+		key := out.Type().Field(i).Tag.Get("mapstructure")
+		if key == "-" {
+			continue
+		}
 
-		// Set customer ID to integer value.
-		t.CustomerID, err = strconv.Atoi(cid)
-		if err != nil {
-			return err
+		set := !out.Field(i).IsZero()
+		if l.Explicit != nil {
+			set = l.Explicit[key]
+		}
+		if !set {
+			out.Field(i).Set(def.Field(i))
 		}
 	}
 
-	// Convert customer id string to float64.
-	if amt, ok := v["load_amount"].(string); ok {
+	return &l
+}
 
-		// If first character is a dollar sign $ remove it before type conversion.
-		if amt[0:1] == "$" {
-			amt = amt[1:]
-		}
+// IsLoad reports whether the result adds funds and uses limit, either as a
+// one-shot load or an authorization hold. Results without a type are loads.
+func (r *Result) IsLoad() bool {
+	return r.Type == "" || r.Type == TypeLoad || r.Type == TypeAuthorization
+}
 
-		// Set load amount to float value.
-		t.LoadAmount, err = strconv.ParseFloat(amt, 64)
-		if err != nil {
-			return err
-		}
+// IsLoadAttempt reports whether the result counts as a load attempt toward
+// the count based limits. Loads rejected for an invalid amount don't count.
+func (r *Result) IsLoadAttempt() bool {
+	return r.IsLoad() && r.Reason != ReasonInvalidAmount
+}
+
+// IsSettled reports whether the result is an accepted load whose funds are
+// final, either a one-shot load or a captured authorization.
+func (r *Result) IsSettled() bool {
+	return r.Accepted && r.IsLoad() && !r.Pending && !r.Voided
+}
+
+// IsHeld reports whether the result is an authorization hold still pending at
+// the given time.
+func (r *Result) IsHeld(at time.Time) bool {
+	return r.Accepted && r.Pending && at.Before(r.ExpiresAt)
+}
+
+// CountsAt reports whether the result uses limit at the given time. Settled
+// loads always count, holds only count until they expire.
+func (r *Result) CountsAt(at time.Time) bool {
+	return r.IsSettled() || r.IsHeld(at)
+}
+
+// NetAmount returns the load amount still counting toward the customer's
+// limits once any reversals are taken off.
+func (r *Result) NetAmount() float64 {
+	return r.LoadAmount - r.ReversedAmount
+}
+
+// SettledAmount returns how much an accepted result changes the funds settled
+// by a run. Loads and captures add their amount and reversals take theirs off.
+// Holds only settle once captured, so authorizations, voids and expired holds
+// add nothing.
+func (r *Result) SettledAmount() float64 {
+	if !r.Accepted {
+		return 0
 	}
 
-	// Convert time string to time.Time.
-	if date, ok := v["time"].(string); ok {
+	switch r.Type {
+	case "", TypeLoad, TypeCapture:
+		return r.LoadAmount
+	case TypeReversal:
+		return -r.LoadAmount
+	}
 
-		// Convert time to time.Time value.
-		format := "2006-01-02T15:04:05Z"
-		t.Time, err = time.Parse(format, date)
-		if err != nil {
-			return err
-		}
+	return 0
+}
+
+// UnmarshalJSON implements a custom scanner for the transaction type.
+func (t *Transaction) UnmarshalJSON(b []byte) error {
+
+	// Decode leniently, leaving missing fields at their zero value.
+	tx, err := (&Decoder{}).Decode(b)
+	if err != nil {
+		return err
 	}
 
+	*t = *tx
 	return nil
 }
 
@@ -121,6 +368,19 @@ func (r *Result) MarshalJSON() ([]byte, error) {
 	res.Accepted = r.Accepted
 	res.Message = r.Message // for debugging enable it in the structs.
 
+	// Add the reason and usage snapshot in verbose mode.
+	if r.Verbose {
+		return json.Marshal(&VerboseOutput{
+			Output:      *res,
+			Reason:      r.Reason,
+			DailyUsed:   r.DailyUsed,
+			DailyLimit:  r.DailyLimit,
+			WeeklyUsed:  r.WeeklyUsed,
+			WeeklyLimit: r.WeeklyLimit,
+			DailyCount:  r.DailyCount,
+		})
+	}
+
 	// Final conversion to json string.
 	b, err := json.Marshal(res)
 	return b, err
@@ -0,0 +1,228 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// currencySymbols maps currency symbols to their ISO 4217 codes. A bare
+// dollar sign doesn't identify a currency so it maps to an empty code.
+var currencySymbols = []struct {
+	symbol string
+	code   string
+}{
+	// Longer symbols first so "US$" isn't read as "$".
+	{"US$", "USD"},
+	{"CA$", "CAD"},
+	{"C$", "CAD"},
+	{"$", ""},
+	{"€", "EUR"},
+	{"£", "GBP"},
+	{"¥", "JPY"},
+}
+
+// ParseMoney parses a money amount given as a JSON number or a string such as
+// "$1,234.56", "CAD 1,234.56" or "1 234,56 $". It returns the amount and the
+// ISO currency code if one could be identified. Thousands separators and the
+// decimal mark are detected from the string, and amounts with more than two
+// decimal places are rejected.
+func ParseMoney(v interface{}) (float64, string, error) {
+	switch val := v.(type) {
+	case json.Number:
+		amount, err := parseNumber(val.String())
+		return amount, "", err
+	case float64:
+		amount, err := parseNumber(strconv.FormatFloat(val, 'f', -1, 64))
+		return amount, "", err
+	case string:
+		return parseMoneyString(val)
+	}
+
+	return 0, "", fmt.Errorf("must be a string or number, got %s", jsonType(v))
+}
+
+// parseMoneyString parses a formatted money string.
+func parseMoneyString(s string) (float64, string, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, "", fmt.Errorf("is empty")
+	}
+
+	// Pull a leading sign off before any currency symbol.
+	negative := false
+	if strings.HasPrefix(s, "-") {
+		negative, s = true, strings.TrimSpace(s[1:])
+	}
+
+	// Strip the currency code or symbol from either end.
+	s, currency, err := stripCurrency(s)
+	if err != nil {
+		return 0, "", err
+	}
+
+	// The sign may also follow the currency, as in "$-12.00".
+	if strings.HasPrefix(s, "-") {
+		if negative {
+			return 0, "", fmt.Errorf("invalid amount %q", s)
+		}
+		negative, s = true, strings.TrimSpace(s[1:])
+	}
+
+	// Normalize separators and parse the plain number.
+	number, err := normalizeNumber(s)
+	if err != nil {
+		return 0, "", err
+	}
+	amount, err := parseNumber(number)
+	if err != nil {
+		return 0, "", err
+	}
+	if negative {
+		amount = -amount
+	}
+
+	return amount, currency, nil
+}
+
+// stripCurrency removes a three letter currency code or a currency symbol
+// from the start or end of s.
+func stripCurrency(s string) (string, string, error) {
+	currency, found := "", false
+
+	// Currency codes are three upper case letters separated by a space.
+	if fields := strings.Fields(s); len(fields) > 1 {
+		if IsCurrencyCode(fields[0]) {
+			currency, found, s = fields[0], true, strings.TrimSpace(s[len(fields[0]):])
+		} else if last := fields[len(fields)-1]; IsCurrencyCode(last) {
+			currency, found, s = last, true, strings.TrimSpace(s[:len(s)-len(last)])
+		}
+	}
+
+	// Currency symbols can also prefix or suffix the amount.
+	for _, cs := range currencySymbols {
+		switch {
+		case strings.HasPrefix(s, cs.symbol):
+			s = strings.TrimSpace(s[len(cs.symbol):])
+		case strings.HasSuffix(s, cs.symbol):
+			s = strings.TrimSpace(s[:len(s)-len(cs.symbol)])
+		default:
+			continue
+		}
+
+		// A symbol must agree with any currency code given.
+		if found && cs.code != "" && cs.code != currency {
+			return "", "", fmt.Errorf("conflicting currencies %s and %s", currency, cs.code)
+		}
+		if !found {
+			currency = cs.code
+		}
+		break
+	}
+
+	return s, currency, nil
+}
+
+// IsCurrencyCode reports whether s looks like an ISO 4217 currency code.
+func IsCurrencyCode(s string) bool {
+	if len(s) != 3 {
+		return false
+	}
+	for _, r := range s {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// normalizeNumber converts a number with locale specific thousands
+// separators and decimal mark into a plain "1234.56" form.
+func normalizeNumber(s string) (string, error) {
+	var (
+		groups  []string
+		group   strings.Builder
+		marks   []rune
+		decimal = rune(0)
+	)
+
+	// Split the number into digit groups and the marks between them.
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			group.WriteRune(r)
+		case r == '.' || r == ',' || r == '\'' || r == '’' || unicode.IsSpace(r):
+			groups = append(groups, group.String())
+			group.Reset()
+			marks = append(marks, r)
+		default:
+			return "", fmt.Errorf("invalid character %q in amount", r)
+		}
+	}
+	groups = append(groups, group.String())
+
+	// Pick the decimal mark. It is the last '.' or ',' unless that mark is
+	// also used elsewhere, or is a lone comma followed by exactly three digits
+	// in which case it separates thousands.
+	if n := len(marks); n > 0 {
+		last := marks[n-1]
+		if last == '.' || last == ',' {
+			repeated := strings.Count(string(marks), string(last)) > 1
+			thousands := last == ',' && len(groups[n]) == 3 && !strings.ContainsRune(string(marks), '.')
+			if !repeated && !thousands {
+				decimal = last
+			}
+		}
+	}
+
+	// Confirm the thousands groups are well formed. A lone decimal part such
+	// as ".50" has an implied zero.
+	whole := groups
+	if decimal != 0 {
+		whole = groups[:len(groups)-1]
+		if len(whole) == 1 && whole[0] == "" {
+			whole = []string{"0"}
+		}
+	}
+	for i, g := range whole {
+		if g == "" || (i > 0 && len(g) != 3) || (i == 0 && len(whole) > 1 && len(g) > 3) {
+			return "", fmt.Errorf("invalid amount %q", s)
+		}
+	}
+
+	// Only one kind of thousands separator may be used.
+	for _, m := range marks[:len(whole)-1] {
+		if m != marks[0] || m == decimal {
+			return "", fmt.Errorf("invalid amount %q", s)
+		}
+	}
+
+	if decimal == 0 {
+		return strings.Join(whole, ""), nil
+	}
+	return strings.Join(whole, "") + "." + groups[len(groups)-1], nil
+}
+
+// parseNumber parses a plain decimal number, rejecting sub-cent precision.
+func parseNumber(s string) (float64, error) {
+	amount, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsInf(amount, 0) || math.IsNaN(amount) {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+
+	// Count decimal places on the literal, falling back to the value for
+	// exponent forms such as 1.5e2.
+	if strings.ContainsAny(s, "eE") {
+		if cents := amount * 100; math.Abs(cents-math.Round(cents)) > 1e-6 {
+			return 0, fmt.Errorf("has more than two decimal places")
+		}
+	} else if i := strings.IndexByte(s, '.'); i >= 0 && len(s)-i-1 > 2 {
+		return 0, fmt.Errorf("has more than two decimal places")
+	}
+
+	return amount, nil
+}
@@ -0,0 +1,52 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+type moneyTest struct {
+	result   bool
+	input    interface{}
+	amount   float64
+	currency string
+}
+
+func TestParseMoney(t *testing.T) {
+
+	// Initialize test cases.
+	tests := []moneyTest{
+		{result: true, input: "$3318.47", amount: 3318.47},
+		{result: true, input: "CAD 1,234.56", amount: 1234.56, currency: "CAD"},
+		{result: true, input: "1 234,56 $", amount: 1234.56},
+		{result: true, input: "1 234,56 €", amount: 1234.56, currency: "EUR"},
+		{result: true, input: "1.234.567,89 EUR", amount: 1234567.89, currency: "EUR"},
+		{result: true, input: "US$1,000", amount: 1000, currency: "USD"},
+		{result: true, input: "12,5", amount: 12.5},
+		{result: true, input: "-$12.00", amount: -12},
+		{result: true, input: "$.50", amount: 0.5},
+		{result: true, input: json.Number("250.75"), amount: 250.75},
+		{result: true, input: json.Number("1.5e2"), amount: 150},
+		{result: false, input: "$12.345"},
+		{result: false, input: json.Number("0.001")},
+		{result: false, input: ""},
+		{result: false, input: "$"},
+		{result: false, input: "12,34,567"},
+		{result: false, input: "1,234.567"},
+		{result: false, input: "USD 12 €"},
+		{result: false, input: "12abc"},
+		{result: false, input: true},
+	}
+
+	// Run test cases.
+	for _, test := range tests {
+		amount, currency, err := ParseMoney(test.input)
+		if test.result != (err == nil) {
+			t.Errorf("amount '%v' expected success '%+v', got error '%+v'", test.input, test.result, err)
+			continue
+		}
+		if test.result && (amount != test.amount || currency != test.currency) {
+			t.Errorf("amount '%v' parsed as %v %s, expected %v %s", test.input, amount, currency, test.amount, test.currency)
+		}
+	}
+}
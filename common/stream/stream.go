@@ -0,0 +1,153 @@
+// Package stream contains helpers for opening the app's input and output
+// streams, transparently handling gzip and zstd compression.
+package stream
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Supported compression codecs.
+const (
+	None = "none"
+	Gzip = "gzip"
+	Zstd = "zstd"
+)
+
+// Stdio is the path used to read from stdin or write to stdout.
+const Stdio = "-"
+
+// Magic bytes at the start of compressed streams.
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// NewReader wraps r with a decompressor if the stream is gzip or zstd
+// compressed. Compression is detected by the magic bytes at the start of the
+// stream, falling back to the extension of name.
+func NewReader(r io.Reader, name string) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+
+	// Peek at the stream header. A short stream simply can't be compressed.
+	header, err := br.Peek(len(zstdMagic))
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	// Pick the codec from the magic bytes first and the extension second.
+	codec := None
+	switch {
+	case bytes.HasPrefix(header, gzipMagic):
+		codec = Gzip
+	case bytes.HasPrefix(header, zstdMagic):
+		codec = Zstd
+	case len(header) > 0:
+		codec = codecFromExt(name)
+	}
+
+	switch codec {
+	case Gzip:
+		return gzip.NewReader(br)
+	case Zstd:
+		d, err := zstd.NewReader(br)
+		if err != nil {
+			return nil, err
+		}
+		return d.IOReadCloser(), nil
+	}
+
+	return io.NopCloser(br), nil
+}
+
+// NewWriter wraps w with a compressor for the given codec. Closing the
+// returned writer flushes the compressor but does not close w.
+func NewWriter(w io.Writer, codec string) (io.WriteCloser, error) {
+	switch codec {
+	case "", None:
+		return nopWriteCloser{w}, nil
+	case Gzip:
+		return gzip.NewWriter(w), nil
+	case Zstd:
+		return zstd.NewWriter(w)
+	}
+
+	return nil, fmt.Errorf("unsupported compression codec %q", codec)
+}
+
+// Create opens the output path for writing, compressing with codec. If codec
+// is empty it is derived from the path extension. A path of "" or "-" writes
+// to stdout.
+func Create(path string, codec string) (io.WriteCloser, error) {
+
+	// Derive the codec from the file extension if not set explicitly.
+	if codec == "" {
+		codec = codecFromExt(path)
+	}
+
+	// Write to stdout, leaving it open once the compressor is closed.
+	if path == "" || path == Stdio {
+		return NewWriter(os.Stdout, codec)
+	}
+
+	// Create the output file.
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Wrap the file with the compressor.
+	w, err := NewWriter(file, codec)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return &fileWriter{WriteCloser: w, file: file}, nil
+}
+
+// codecFromExt returns the compression codec matching a file extension.
+func codecFromExt(name string) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".gz", ".gzip":
+		return Gzip
+	case ".zst", ".zstd":
+		return Zstd
+	}
+
+	return None
+}
+
+// nopWriteCloser adds a no-op Close method to an io.Writer.
+type nopWriteCloser struct {
+	io.Writer
+}
+
+// Close does nothing.
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+// fileWriter closes both the compressor and its underlying file.
+type fileWriter struct {
+	io.WriteCloser
+	file *os.File
+}
+
+// Close flushes the compressor before closing the file.
+func (f *fileWriter) Close() error {
+	if err := f.WriteCloser.Close(); err != nil {
+		f.file.Close()
+		return err
+	}
+
+	return f.file.Close()
+}
@@ -0,0 +1,68 @@
+package stream
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+type test struct {
+	codec string
+	name  string
+}
+
+func TestRoundTrip(t *testing.T) {
+
+	// Initialize test cases.
+	tests := []test{
+		{codec: None, name: "input.txt"},
+		{codec: Gzip, name: "input.txt"},
+		{codec: Zstd, name: "input.txt"},
+		{codec: Gzip, name: "input.txt.gz"},
+	}
+
+	// Run test cases.
+	for _, test := range tests {
+		data := []byte(`{"id":"1","customer_id":"1","load_amount":"$1.00","time":"2000-01-01T00:00:00Z"}`)
+
+		// Compress the data with the test codec.
+		var buf bytes.Buffer
+		w, err := NewWriter(&buf, test.codec)
+		if err != nil {
+			t.Fatalf("unable to create %s writer: %+v", test.codec, err)
+		}
+		w.Write(data)
+		w.Close()
+
+		// Detect and decompress it again.
+		r, err := NewReader(&buf, test.name)
+		if err != nil {
+			t.Fatalf("unable to create reader for %s: %+v", test.codec, err)
+		}
+		out, err := io.ReadAll(r)
+		if err != nil {
+			t.Errorf("unable to read %s data: %+v", test.codec, err)
+		}
+		if !bytes.Equal(out, data) {
+			t.Errorf("%s round trip returned '%s', expected '%s'", test.codec, out, data)
+		}
+	}
+}
+
+func TestNewWriter(t *testing.T) {
+	if _, err := NewWriter(&bytes.Buffer{}, "lz4"); err == nil {
+		t.Error("expected unsupported codec to fail")
+	}
+}
+
+func TestCodecFromExt(t *testing.T) {
+	if codec := codecFromExt("loads.txt.gz"); codec != Gzip {
+		t.Errorf("expected gzip codec, got '%s'", codec)
+	}
+	if codec := codecFromExt("loads.txt.zst"); codec != Zstd {
+		t.Errorf("expected zstd codec, got '%s'", codec)
+	}
+	if codec := codecFromExt("loads.txt"); codec != None {
+		t.Errorf("expected no codec, got '%s'", codec)
+	}
+}
@@ -3,42 +3,214 @@
 package conf
 
 import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nkarpenko/koho-transaction/balance"
+	"github.com/nkarpenko/koho-transaction/common/cache"
 	"github.com/nkarpenko/koho-transaction/common/model"
+	"github.com/nkarpenko/koho-transaction/fx"
+	"github.com/nkarpenko/koho-transaction/group"
+	"github.com/nkarpenko/koho-transaction/registry"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
 // Config of the service.
 type Config struct {
-	Name       string        `mapstructure:"name"`
-	Desc       string        `mapstructure:"desc"`
-	InputFile  string        `mapstructure:"input"`
-	OutputFile string        `mapstructure:"output"`
-	Limits     *model.Limits `mapstructure:"limits"`
-	Version    string        `mapstructure:"version"`
+	File              string                   `mapstructure:"-"`
+	Name              string                   `mapstructure:"name"`
+	Desc              string                   `mapstructure:"desc"`
+	InputFile         string                   `mapstructure:"input"`
+	InputFiles        []string                 `mapstructure:"inputs"`
+	OutputFile        string                   `mapstructure:"output"`
+	OutputCompression string                   `mapstructure:"output_compression"`
+	SummaryFile       string                   `mapstructure:"summary"`
+	Strict            bool                     `mapstructure:"strict"`
+	Verbose           bool                     `mapstructure:"verbose"`
+	TimeLayouts       []string                 `mapstructure:"time_layouts"`
+	HoldExpiry        time.Duration            `mapstructure:"hold_expiry"`
+	Currency          string                   `mapstructure:"currency"`
+	FXRatesFile       string                   `mapstructure:"fx_rates"`
+	Rates             *fx.Table                `mapstructure:"-"`
+	GroupsFile        string                   `mapstructure:"groups"`
+	Groups            *group.Groups            `mapstructure:"-"`
+	RegistryFile      string                   `mapstructure:"registry"`
+	Registry          *registry.Registry       `mapstructure:"-"`
+	BalancesFile      string                   `mapstructure:"balances"`
+	Balances          *balance.Balances        `mapstructure:"-"`
+	Store             *cache.Store             `mapstructure:"-"`
+	Limits            *model.Limits            `mapstructure:"limits"`
+	KYCLimits         map[string]*model.Limits `mapstructure:"kyc_limits"`
+	Version           string                   `mapstructure:"version"`
+}
+
+// Inputs returns every configured input path or glob pattern. The list form
+// takes precedence over the single input path when both are supplied.
+func (c *Config) Inputs() []string {
+	if len(c.InputFiles) > 0 {
+		return c.InputFiles
+	}
+
+	// Fall back to the single input path if one is set.
+	if c.InputFile != "" {
+		return []string{c.InputFile}
+	}
+
+	return nil
+}
+
+// EnvPrefix is prepended to the environment variable overriding each config
+// key, for example KOHO_LIMITS_DAILY_AMOUNT for limits.daily_amount.
+const EnvPrefix = "KOHO"
+
+// SetFlag is the repeatable key=value flag that can override any scalar
+// config key, such as --set limits.max_balance=3000.
+const SetFlag = "set"
+
+// flagKeys maps the CLI flags that override a single config value to its key.
+var flagKeys = map[string]string{
+	"input":              "inputs",
+	"output":             "output",
+	"verbose":            "verbose",
+	"daily-amount":       "limits.daily_amount",
+	"weekly-amount":      "limits.weekly_amount",
+	"daily-transactions": "limits.daily_transactions",
 }
 
 // Load the config file
 func Load(file string) (*Config, error) {
+	return LoadFlags(file, nil)
+}
+
+// LoadFlags loads the config file, overriding its values with any KOHO_
+// environment variables and then with any of the flags in flagKeys or SetFlag
+// that were set. Flags take precedence over the environment, which takes
+// precedence over the file. Without a file, config.yml in the working
+// directory is used if it exists, otherwise the environment and flags alone
+// make up the config.
+func LoadFlags(file string, flags *pflag.FlagSet) (*Config, error) {
 	var config *Config
 
+	// Each load gets its own viper instance so configs can load in parallel.
+	v := viper.New()
+
 	// Set config file type to yml and define default file.
-	viper.SetConfigType("yml")
+	v.SetConfigType("yml")
 	if file != "" {
-		viper.SetConfigFile(file)
+		v.SetConfigFile(file)
 	} else {
-		viper.SetConfigName("config")
+		v.SetConfigName("config")
+		v.AddConfigPath(".")
 	}
 
-	// Read and load the config vars.
-	if err := viper.ReadInConfig(); err != nil {
+	// Read and load the config vars. Only a default file may be missing.
+	var notFound viper.ConfigFileNotFoundError
+	if err := v.ReadInConfig(); err != nil && !(file == "" && errors.As(err, &notFound)) {
 		return config, err
 	}
 
+	// Bind an environment variable to every key, including keys missing from
+	// the file which viper wouldn't otherwise look up.
+	v.SetEnvPrefix(EnvPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	for _, key := range scalarKeys() {
+		if err := v.BindEnv(key); err != nil {
+			return config, err
+		}
+	}
+
+	// Bind the flags that were set on the CLI.
+	if flags != nil {
+		for name, key := range flagKeys {
+			if f := flags.Lookup(name); f != nil && f.Changed {
+				if err := v.BindPFlag(key, f); err != nil {
+					return config, err
+				}
+			}
+		}
+
+		// Apply any key=value overrides in the order given.
+		if f := flags.Lookup(SetFlag); f != nil && f.Changed {
+			sets, err := flags.GetStringArray(SetFlag)
+			if err != nil {
+				return config, err
+			}
+			for _, set := range sets {
+				key, value, ok := strings.Cut(set, "=")
+				key = strings.ToLower(strings.TrimSpace(key))
+				if !ok || !contains(scalarKeys(), key) {
+					return config, ValidationError{fmt.Sprintf("invalid --%s %q, expected key=value for a config key", SetFlag, set)}
+				}
+				v.Set(key, value)
+			}
+		}
+	}
+
 	// Parse config into struct.
 	config = new(Config)
-	if err := viper.Unmarshal(config); err != nil {
+	if err := v.Unmarshal(config); err != nil {
 		return config, err
 	}
+	config.File = v.ConfigFileUsed()
+
+	// Record the limits each KYC level sets, so a zero can disable a default.
+	for _, key := range v.AllKeys() {
+		parts := strings.Split(key, ".")
+		if len(parts) != 3 || parts[0] != "kyc_limits" {
+			continue
+		}
+		if limits := config.KYCLimits[parts[1]]; limits != nil {
+			if limits.Explicit == nil {
+				limits.Explicit = map[string]bool{}
+			}
+			limits.Explicit[parts[2]] = true
+		}
+	}
+
+	// Confirm every value is present and valid, and reject keys the config
+	// doesn't define such as misspelt limits.
+	if errs := append(config.validate(), unknownKeys(v.AllKeys())...); len(errs) > 0 {
+		return config, errs
+	}
+
+	// Load the FX rate table if one is configured.
+	if config.FXRatesFile != "" {
+		rates, err := fx.Load(config.FXRatesFile)
+		if err != nil {
+			return config, err
+		}
+		config.Rates = rates
+	}
+
+	// Load the linked customer groups if configured.
+	if config.GroupsFile != "" {
+		groups, err := group.Load(config.GroupsFile)
+		if err != nil {
+			return config, err
+		}
+		config.Groups = groups
+	}
+
+	// Load the customer profile registry if configured.
+	if config.RegistryFile != "" {
+		reg, err := registry.Load(config.RegistryFile)
+		if err != nil {
+			return config, err
+		}
+		config.Registry = reg
+	}
+
+	// Load the opening account balances if configured.
+	if config.BalancesFile != "" {
+		balances, err := balance.Load(config.BalancesFile)
+		if err != nil {
+			return config, err
+		}
+		config.Balances = balances
+	}
 
 	return config, nil
 }
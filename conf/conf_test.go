@@ -1,7 +1,13 @@
 package conf
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/spf13/pflag"
 )
 
 type test struct {
@@ -22,9 +28,13 @@ func TestLoad(t *testing.T) {
 			file:   "./invalid_input.txt",
 		},
 		{
-			result: true,
+			result: false,
 			file:   "../input.txt",
 		},
+		{
+			result: true,
+			file:   "../config.yml",
+		},
 	}
 
 	// Run test cases.
@@ -37,5 +47,315 @@ func TestLoad(t *testing.T) {
 		if test.result && conf == nil {
 			t.Errorf("unable to load taml configuration file: %+v", err)
 		}
+
+		if !test.result && err == nil {
+			t.Errorf("expected %s to fail to load", test.file)
+		}
+	}
+}
+
+type validateTest struct {
+	name   string
+	config string
+	errors []string
+}
+
+func TestValidate(t *testing.T) {
+	dir := t.TempDir()
+
+	// Initialize test cases.
+	tests := []validateTest{
+		{
+			name: "valid",
+			config: `input: ./input.txt
+limits:
+  daily_amount: 5000
+  weekly_amount: 20000
+  daily_transactions: 3
+kyc_limits:
+  basic:
+    daily_amount: 1000
+`,
+		},
+		{
+			name:   "missing limits",
+			config: "input: ./input.txt\n",
+			errors: []string{"limits is required"},
+		},
+		{
+			name: "invalid values",
+			config: `output_compression: brotli
+currency: dollars
+limits:
+  daily_amount: 0
+  weekly_amount: -1
+  daily_transactions: 3
+  min_load_amount: 100
+  max_load_amount: 50
+  velocity_loads: 2
+kyc_limits:
+  basic:
+    max_balance: -10
+`,
+			errors: []string{
+				`output_compression must be one of none, gzip or zstd, got "brotli"`,
+				`currency must be a three letter currency code, got "dollars"`,
+				"limits.daily_amount must be greater than zero",
+				"limits.weekly_amount must not be negative",
+				"limits.min_load_amount must not be greater than max_load_amount",
+				"limits.velocity_loads needs a velocity_window",
+				"kyc_limits.basic.max_balance must not be negative",
+			},
+		},
+		{
+			name: "unknown keys",
+			config: `input: ./input.txt
+outptu: out.txt
+limits:
+  daily_amount: 5000
+  weekly_amount: 20000
+  daily_transactions: 3
+  daily_amonut: 10
+`,
+			errors: []string{
+				`unknown key "limits.daily_amonut"`,
+				`unknown key "outptu"`,
+			},
+		},
+	}
+
+	// Run test cases.
+	for i, test := range tests {
+		file := filepath.Join(dir, strings.ReplaceAll(test.name, " ", "_")+".yml")
+		if err := os.WriteFile(file, []byte(test.config), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		_, err := Load(file)
+		if len(test.errors) == 0 {
+			if err != nil {
+				t.Errorf("test %d (%s): unexpected error: %v", i, test.name, err)
+			}
+			continue
+		}
+
+		verr, ok := err.(ValidationError)
+		if !ok {
+			t.Errorf("test %d (%s): expected a validation error, got %v", i, test.name, err)
+			continue
+		}
+		if strings.Join(verr, "\n") != strings.Join(test.errors, "\n") {
+			t.Errorf("test %d (%s): expected errors\n%s\ngot\n%s", i, test.name, strings.Join(test.errors, "\n"), strings.Join(verr, "\n"))
+		}
+	}
+}
+
+type overrideTest struct {
+	name   string
+	env    map[string]string
+	flags  []string
+	daily  int
+	inputs []string
+	output string
+}
+
+func TestOverrides(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yml")
+	config := `input: ./input.txt
+output: "-"
+limits:
+  daily_amount: 5000
+  weekly_amount: 20000
+  daily_transactions: 3
+`
+	if err := os.WriteFile(file, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Initialize test cases.
+	tests := []overrideTest{
+		{
+			name:   "file",
+			daily:  5000,
+			inputs: []string{"./input.txt"},
+			output: "-",
+		},
+		{
+			name:   "env",
+			env:    map[string]string{"KOHO_LIMITS_DAILY_AMOUNT": "6000", "KOHO_INPUTS": "a.txt,b.txt", "KOHO_OUTPUT": "out.txt"},
+			daily:  6000,
+			inputs: []string{"a.txt", "b.txt"},
+			output: "out.txt",
+		},
+		{
+			name:   "flags over env",
+			env:    map[string]string{"KOHO_LIMITS_DAILY_AMOUNT": "6000", "KOHO_OUTPUT": "out.txt"},
+			flags:  []string{"--daily-amount", "7000", "--input", "c.txt", "--output", "flag.txt"},
+			daily:  7000,
+			inputs: []string{"c.txt"},
+			output: "flag.txt",
+		},
+		{
+			name:   "set flag",
+			env:    map[string]string{"KOHO_LIMITS_DAILY_AMOUNT": "6000"},
+			flags:  []string{"--set", "limits.daily_amount=8000", "--set", "inputs=d.txt,e.txt"},
+			daily:  8000,
+			inputs: []string{"d.txt", "e.txt"},
+			output: "-",
+		},
+		{
+			name:   "unset flags",
+			env:    map[string]string{"KOHO_LIMITS_DAILY_AMOUNT": "6000"},
+			flags:  []string{"--output", "flag.txt"},
+			daily:  6000,
+			inputs: []string{"./input.txt"},
+			output: "flag.txt",
+		},
+	}
+
+	// Run test cases.
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for key, value := range test.env {
+				t.Setenv(key, value)
+			}
+
+			flags := pflag.NewFlagSet(test.name, pflag.ContinueOnError)
+			flags.StringArray("input", nil, "")
+			flags.String("output", "", "")
+			flags.Int("daily-amount", 0, "")
+			flags.StringArray(SetFlag, nil, "")
+			if err := flags.Parse(test.flags); err != nil {
+				t.Fatal(err)
+			}
+
+			c, err := LoadFlags(file, flags)
+			if err != nil {
+				t.Fatalf("test %d: unexpected error: %v", i, err)
+			}
+			if c.Limits.DailyAmount != test.daily {
+				t.Errorf("test %d: expected daily amount %d, got %d", i, test.daily, c.Limits.DailyAmount)
+			}
+			if strings.Join(c.Inputs(), ",") != strings.Join(test.inputs, ",") {
+				t.Errorf("test %d: expected inputs %v, got %v", i, test.inputs, c.Inputs())
+			}
+			if c.OutputFile != test.output {
+				t.Errorf("test %d: expected output %q, got %q", i, test.output, c.OutputFile)
+			}
+		})
+	}
+}
+
+func TestLoadWithoutFile(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	// A missing default file leaves the environment to make up the config.
+	t.Setenv("KOHO_INPUTS", "input.txt")
+	t.Setenv("KOHO_LIMITS_DAILY_AMOUNT", "5000")
+	t.Setenv("KOHO_LIMITS_WEEKLY_AMOUNT", "20000")
+	t.Setenv("KOHO_LIMITS_DAILY_TRANSACTIONS", "3")
+	c, err := Load("")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if c.File != "" || c.Limits.DailyAmount != 5000 || c.Inputs()[0] != "input.txt" {
+		t.Errorf("expected config from the environment, got %+v", c)
+	}
+
+	// A missing file that was asked for is still an error.
+	if _, err := Load("config.yml"); err == nil {
+		t.Error("expected a missing config file to fail to load")
+	}
+
+	// Unknown keys can't be set with the set flag.
+	flags := pflag.NewFlagSet("set", pflag.ContinueOnError)
+	flags.StringArray(SetFlag, nil, "")
+	if err := flags.Parse([]string{"--set", "limits.daily_amonut=10"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadFlags("", flags); err == nil {
+		t.Error("expected an unknown set key to fail to load")
+	}
+}
+
+func TestLoadParallel(t *testing.T) {
+	dir := t.TempDir()
+
+	// Load configs with different limits side by side.
+	for i := 1; i <= 8; i++ {
+		daily := i * 1000
+		file := filepath.Join(dir, fmt.Sprintf("config%d.yml", i))
+		config := fmt.Sprintf("input: ./input.txt\nlimits:\n  daily_amount: %d\n  weekly_amount: 20000\n  daily_transactions: 3\n", daily)
+		if err := os.WriteFile(file, []byte(config), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			t.Parallel()
+			for n := 0; n < 20; n++ {
+				c, err := Load(file)
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if c.Limits.DailyAmount != daily {
+					t.Fatalf("expected daily amount %d, got %d", daily, c.Limits.DailyAmount)
+				}
+			}
+		})
+	}
+}
+
+func TestKYCLimits(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config.yml")
+	config := `input: ./input.txt
+limits:
+  daily_amount: 5000
+  weekly_amount: 20000
+  daily_transactions: 3
+  velocity_loads: 2
+  velocity_window: 10m
+kyc_limits:
+  basic:
+    daily_amount: 1000
+  trusted:
+    velocity_loads: 0
+`
+	if err := os.WriteFile(file, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := Load(file)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Levels inherit the limits they leave unset.
+	basic := c.KYCLimits["basic"].WithDefaults(c.Limits)
+	if basic.DailyAmount != 1000 || basic.VelocityLoads != 2 {
+		t.Errorf("expected basic limits to inherit the velocity limit, got '%+v'", basic)
+	}
+
+	// A zero limit disables the default rather than inheriting it.
+	trusted := c.KYCLimits["trusted"].WithDefaults(c.Limits)
+	if trusted.DailyAmount != 5000 || trusted.VelocityLoads != 0 {
+		t.Errorf("expected trusted limits to disable the velocity limit, got '%+v'", trusted)
+	}
+
+	// The daily and weekly limits can't be turned off for a level.
+	config += "    daily_amount: 0\n"
+	if err := os.WriteFile(file, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err = Load(file)
+	verr, ok := err.(ValidationError)
+	if !ok || len(verr) != 1 || verr[0] != "kyc_limits.trusted.daily_amount must be greater than zero" {
+		t.Errorf("expected a zero level daily amount to be rejected, got '%v'", err)
 	}
 }
@@ -0,0 +1,214 @@
+package conf
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/nkarpenko/koho-transaction/common/model"
+	"github.com/nkarpenko/koho-transaction/common/stream"
+)
+
+// Supported output compression codecs.
+var compressionCodecs = []string{"", stream.None, stream.Gzip, stream.Zstd}
+
+// ValidationError holds every problem found in a config.
+type ValidationError []string
+
+// Error implements the error interface.
+func (e ValidationError) Error() string {
+	return "invalid config:\n\t" + strings.Join(e, "\n\t")
+}
+
+// Validate method checks the config for missing and invalid values, returning
+// every problem found as a ValidationError.
+func (c *Config) Validate() error {
+	if errs := c.validate(); len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// validate returns every missing or invalid value in the config.
+func (c *Config) validate() ValidationError {
+	var errs ValidationError
+
+	// Confirm the output, hold and currency settings are usable.
+	if !contains(compressionCodecs, c.OutputCompression) {
+		errs = append(errs, fmt.Sprintf("output_compression must be one of none, gzip or zstd, got %q", c.OutputCompression))
+	}
+	if c.HoldExpiry < 0 {
+		errs = append(errs, "hold_expiry must not be negative")
+	}
+	if c.Currency != "" && !model.IsCurrencyCode(strings.ToUpper(c.Currency)) {
+		errs = append(errs, fmt.Sprintf("currency must be a three letter currency code, got %q", c.Currency))
+	}
+
+	// The daily and weekly limits are always enforced so must be set.
+	if c.Limits == nil {
+		errs = append(errs, "limits is required")
+	} else {
+		errs = append(errs, requiredLimits("limits", c.Limits)...)
+		errs = append(errs, validateLimits("limits", c.Limits)...)
+	}
+
+	// KYC levels may leave limits unset or disable the optional ones but
+	// can't set them below zero or turn off the daily and weekly limits.
+	// Required and related limits are checked with the defaults filled in,
+	// unless the defaults themselves already fail those checks.
+	defaultsSet := c.Limits != nil && len(requiredLimits("limits", c.Limits)) == 0
+	defaultsAgree := c.Limits == nil || len(pairedLimits("limits", c.Limits)) == 0
+	levels := make([]string, 0, len(c.KYCLimits))
+	for level := range c.KYCLimits {
+		levels = append(levels, level)
+	}
+	sort.Strings(levels)
+	for _, level := range levels {
+		limits := c.KYCLimits[level]
+		if limits == nil {
+			continue
+		}
+		errs = append(errs, negativeLimits("kyc_limits."+level, limits)...)
+		if defaultsSet {
+			errs = append(errs, requiredLimits("kyc_limits."+level, limits.WithDefaults(c.Limits))...)
+		}
+		if defaultsAgree {
+			errs = append(errs, pairedLimits("kyc_limits."+level, limits.WithDefaults(c.Limits))...)
+		}
+	}
+
+	return errs
+}
+
+// requiredLimits returns an error for each of the daily and weekly limits left
+// at zero. They are always enforced, so zero would reject every load.
+// Negative values are reported with the rest of the limits.
+func requiredLimits(prefix string, l *model.Limits) []string {
+	var errs []string
+
+	if l.DailyAmount == 0 {
+		errs = append(errs, prefix+".daily_amount must be greater than zero")
+	}
+	if l.WeeklyAmount == 0 {
+		errs = append(errs, prefix+".weekly_amount must be greater than zero")
+	}
+	if l.DailyTransactions == 0 {
+		errs = append(errs, prefix+".daily_transactions must be greater than zero")
+	}
+
+	return errs
+}
+
+// validateLimits checks that no limit is negative and that related limits
+// agree with each other.
+func validateLimits(prefix string, l *model.Limits) []string {
+	return append(negativeLimits(prefix, l), pairedLimits(prefix, l)...)
+}
+
+// negativeLimits returns an error for every limit below zero. Every limit is
+// disabled at zero, so only negative values are invalid.
+func negativeLimits(prefix string, l *model.Limits) []string {
+	var errs []string
+
+	v := reflect.ValueOf(l).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		f := v.Field(i)
+		negative := false
+		switch f.Kind() {
+		case reflect.Int, reflect.Int64:
+			negative = f.Int() < 0
+		case reflect.Float64:
+			negative = f.Float() < 0
+		}
+		if negative {
+			errs = append(errs, fmt.Sprintf("%s.%s must not be negative", prefix, v.Type().Field(i).Tag.Get("mapstructure")))
+		}
+	}
+
+	return errs
+}
+
+// pairedLimits returns an error for every pair of related limits that don't
+// make sense together.
+func pairedLimits(prefix string, l *model.Limits) []string {
+	var errs []string
+
+	if l.MinLoadAmount > 0 && l.MaxLoadAmount > 0 && l.MinLoadAmount > l.MaxLoadAmount {
+		errs = append(errs, fmt.Sprintf("%s.min_load_amount must not be greater than max_load_amount", prefix))
+	}
+	if l.VelocityLoads > 0 && l.VelocityWindow <= 0 {
+		errs = append(errs, fmt.Sprintf("%s.velocity_loads needs a velocity_window", prefix))
+	}
+
+	return errs
+}
+
+// unknownKeys returns an error for every config key the Config struct doesn't
+// define, such as a misspelt limit.
+func unknownKeys(keys []string) ValidationError {
+	var errs ValidationError
+
+	top := tagNames(reflect.TypeOf(Config{}))
+	limits := tagNames(reflect.TypeOf(model.Limits{}))
+	for _, key := range keys {
+		parts := strings.Split(key, ".")
+
+		var known bool
+		switch {
+		case len(parts) == 1:
+			known = top[parts[0]]
+		case parts[0] == "limits" && len(parts) == 2:
+			known = limits[parts[1]]
+		case parts[0] == "kyc_limits" && len(parts) == 3:
+			known = limits[parts[2]]
+		}
+		if !known {
+			errs = append(errs, fmt.Sprintf("unknown key %q", key))
+		}
+	}
+
+	sort.Strings(errs)
+	return errs
+}
+
+// scalarKeys returns every config key that can be set from the environment or
+// with SetFlag. The limit maps are set one limit at a time.
+func scalarKeys() []string {
+	var keys []string
+	for key := range tagNames(reflect.TypeOf(Config{})) {
+		if key != "limits" && key != "kyc_limits" {
+			keys = append(keys, key)
+		}
+	}
+	for key := range tagNames(reflect.TypeOf(model.Limits{})) {
+		keys = append(keys, "limits."+key)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
+
+// tagNames returns the mapstructure names of a struct's fields.
+func tagNames(t reflect.Type) map[string]bool {
+	names := map[string]bool{}
+	for i := 0; i < t.NumField(); i++ {
+		if tag := t.Field(i).Tag.Get("mapstructure"); tag != "" && tag != "-" {
+			names[tag] = true
+		}
+	}
+
+	return names
+}
+
+// contains reports whether s is in list.
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+
+	return false
+}
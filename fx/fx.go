@@ -0,0 +1,113 @@
+// Package fx contains a dated foreign exchange rate table used to convert
+// transaction amounts into the currency limits are set in.
+package fx
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DateLayout is the layout of the effective date column in a rates file.
+const DateLayout = "2006-01-02"
+
+// Rate struct holds a single dated exchange rate. Rate is the amount of the
+// limit currency one unit of Currency buys from Date onward.
+type Rate struct {
+	Date     time.Time
+	Currency string
+	Rate     float64
+}
+
+// Table struct holds every known rate grouped by currency and sorted by date.
+type Table struct {
+	rates map[string][]Rate
+}
+
+// Rate method returns the latest rate for the currency effective at the given
+// time.
+func (t *Table) Rate(currency string, at time.Time) (float64, error) {
+	rates := t.rates[strings.ToUpper(currency)]
+
+	// Find the first rate after the given time, the one before it applies.
+	i := sort.Search(len(rates), func(i int) bool {
+		return rates[i].Date.After(at)
+	})
+	if i == 0 {
+		return 0, fmt.Errorf("no %s exchange rate effective at %s", currency, at.Format(time.RFC3339))
+	}
+
+	return rates[i-1].Rate, nil
+}
+
+// Load reads a CSV rates file with a "date,currency,rate" header, for example
+// "2000-01-01,USD,1.35".
+func Load(path string) (*Table, error) {
+
+	// Try and open the rates file.
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return Read(file)
+}
+
+// Read parses CSV rates from r.
+func Read(r io.Reader) (*Table, error) {
+	var rates []Rate
+
+	// Read every record, including the header.
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, errors.New("rates file is empty")
+	}
+
+	// Convert each record after the header into a rate.
+	for i, record := range records[1:] {
+		if len(record) != 3 {
+			return nil, fmt.Errorf("rates line %d: expected date, currency and rate", i+2)
+		}
+
+		date, err := time.Parse(DateLayout, strings.TrimSpace(record[0]))
+		if err != nil {
+			return nil, fmt.Errorf("rates line %d: invalid date: %v", i+2, err)
+		}
+		rate, err := strconv.ParseFloat(strings.TrimSpace(record[2]), 64)
+		if err != nil || rate <= 0 {
+			return nil, fmt.Errorf("rates line %d: rate must be a positive number", i+2)
+		}
+
+		rates = append(rates, Rate{Date: date, Currency: strings.TrimSpace(record[1]), Rate: rate})
+	}
+
+	return New(rates...), nil
+}
+
+// New rate table from a list of rates.
+func New(rates ...Rate) *Table {
+	t := &Table{rates: map[string][]Rate{}}
+	for _, rate := range rates {
+		rate.Currency = strings.ToUpper(rate.Currency)
+		t.rates[rate.Currency] = append(t.rates[rate.Currency], rate)
+	}
+
+	// Sort each currency's rates by their effective date.
+	for _, rates := range t.rates {
+		sort.SliceStable(rates, func(i, j int) bool {
+			return rates[i].Date.Before(rates[j].Date)
+		})
+	}
+
+	return t
+}
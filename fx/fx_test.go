@@ -0,0 +1,70 @@
+package fx
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+type test struct {
+	result   bool
+	currency string
+	at       time.Time
+	rate     float64
+}
+
+func TestRate(t *testing.T) {
+	table, err := Read(strings.NewReader(`date,currency,rate
+2000-01-08,USD,1.40
+2000-01-01,USD,1.35
+2000-01-01,eur,1.50
+`))
+	if err != nil {
+		t.Fatalf("unable to read rates: %+v", err)
+	}
+
+	// Initialize test cases.
+	tests := []test{
+		{result: true, currency: "USD", at: time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC), rate: 1.35},
+		{result: true, currency: "USD", at: time.Date(2000, 1, 7, 23, 59, 59, 0, time.UTC), rate: 1.35},
+		{result: true, currency: "USD", at: time.Date(2000, 1, 8, 0, 0, 0, 0, time.UTC), rate: 1.40},
+		{result: true, currency: "EUR", at: time.Date(2000, 2, 1, 0, 0, 0, 0, time.UTC), rate: 1.50},
+		{result: false, currency: "USD", at: time.Date(1999, 12, 31, 0, 0, 0, 0, time.UTC)},
+		{result: false, currency: "GBP", at: time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	// Run test cases.
+	for _, test := range tests {
+		rate, err := table.Rate(test.currency, test.at)
+		if test.result != (err == nil) {
+			t.Errorf("%s rate at '%v' expected success '%+v', got error '%+v'", test.currency, test.at, test.result, err)
+		}
+		if test.result && rate != test.rate {
+			t.Errorf("%s rate at '%v' was %v, expected %v", test.currency, test.at, rate, test.rate)
+		}
+	}
+}
+
+func TestRead(t *testing.T) {
+
+	// Initialize invalid rate files.
+	tests := []string{
+		"",
+		"date,currency,rate\n2000-01-01,USD\n",
+		"date,currency,rate\n01/01/2000,USD,1.35\n",
+		"date,currency,rate\n2000-01-01,USD,-1\n",
+	}
+
+	// Run test cases.
+	for _, test := range tests {
+		if _, err := Read(strings.NewReader(test)); err == nil {
+			t.Errorf("expected rates '%s' to fail", test)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	if _, err := Load("./invalid_rates.csv"); err == nil {
+		t.Error("expected missing rates file to fail")
+	}
+}
@@ -0,0 +1,158 @@
+// Package group contains the mapping of linked customer accounts, such as
+// households and businesses, that share one set of limits.
+package group
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// Groups struct holds the group of every linked customer and the members of
+// every group.
+type Groups struct {
+	groups  map[int]string
+	members map[string][]int
+}
+
+// Group method returns the group the customer belongs to, if any.
+func (g *Groups) Group(customerID int) (string, bool) {
+	if g == nil {
+		return "", false
+	}
+
+	name, ok := g.groups[customerID]
+	return name, ok
+}
+
+// Members method returns every customer in the same group as the customer,
+// including the customer itself. Customers without a group have no members.
+func (g *Groups) Members(customerID int) []int {
+	name, ok := g.Group(customerID)
+	if !ok {
+		return nil
+	}
+
+	return g.members[name]
+}
+
+// Add method links a customer to a group. A customer can only be in one group.
+func (g *Groups) Add(customerID int, name string) error {
+	if existing, ok := g.groups[customerID]; ok && existing != name {
+		return fmt.Errorf("customer %d is in both groups %q and %q", customerID, existing, name)
+	}
+	if _, ok := g.groups[customerID]; ok {
+		return nil
+	}
+
+	g.groups[customerID] = name
+	g.members[name] = append(g.members[name], customerID)
+	return nil
+}
+
+// Load reads the customer groups from a YAML or CSV file, picked by the file
+// extension.
+//
+// YAML files list the customer ids of each group:
+//
+//	groups:
+//	  household-1: [528, 154]
+//
+// CSV files have a "customer_id,group" header and one customer per line.
+func Load(path string) (*Groups, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+
+		return ReadCSV(file)
+	case ".yml", ".yaml":
+		return loadYAML(path)
+	}
+
+	return nil, fmt.Errorf("unsupported groups file type %q", filepath.Ext(path))
+}
+
+// loadYAML reads the customer groups from a YAML file.
+func loadYAML(path string) (*Groups, error) {
+	var file struct {
+		Groups map[string][]int `mapstructure:"groups"`
+	}
+
+	// Use a separate viper instance so the app config isn't touched.
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, err
+	}
+	if err := v.Unmarshal(&file); err != nil {
+		return nil, err
+	}
+
+	// Add the groups in a stable order so errors are reproducible.
+	names := make([]string, 0, len(file.Groups))
+	for name := range file.Groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	g := New()
+	for _, name := range names {
+		for _, customerID := range file.Groups[name] {
+			if err := g.Add(customerID, name); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return g, nil
+}
+
+// ReadCSV reads the customer groups from CSV data with a "customer_id,group"
+// header.
+func ReadCSV(r io.Reader) (*Groups, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, errors.New("groups file is empty")
+	}
+
+	// Link each customer after the header to its group.
+	g := New()
+	for i, record := range records[1:] {
+		if len(record) != 2 {
+			return nil, fmt.Errorf("groups line %d: expected customer_id and group", i+2)
+		}
+
+		customerID, err := strconv.Atoi(strings.TrimSpace(record[0]))
+		if err != nil {
+			return nil, fmt.Errorf("groups line %d: invalid customer id %q", i+2, record[0])
+		}
+		if err := g.Add(customerID, strings.TrimSpace(record[1])); err != nil {
+			return nil, fmt.Errorf("groups line %d: %v", i+2, err)
+		}
+	}
+
+	return g, nil
+}
+
+// New empty groups instance.
+func New() *Groups {
+	return &Groups{
+		groups:  map[int]string{},
+		members: map[string][]int{},
+	}
+}
@@ -0,0 +1,81 @@
+package group
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type test struct {
+	result bool
+	name   string
+	data   string
+}
+
+func TestLoad(t *testing.T) {
+
+	// Initialize test cases.
+	tests := []test{
+		{
+			result: true,
+			name:   "groups.yml",
+			data:   "groups:\n  household-1: [528, 154]\n  business-1: [\"426\"]\n",
+		},
+		{
+			result: true,
+			name:   "groups.csv",
+			data:   "customer_id,group\n528,household-1\n154,household-1\n426,business-1\n",
+		},
+		{
+			result: false,
+			name:   "groups.yml",
+			data:   "groups:\n  household-1: [528, 154]\n  business-1: [528]\n",
+		},
+		{
+			result: false,
+			name:   "groups.json",
+			data:   "{}",
+		},
+	}
+
+	// Run test cases.
+	for _, test := range tests {
+		path := filepath.Join(t.TempDir(), test.name)
+		if err := os.WriteFile(path, []byte(test.data), 0644); err != nil {
+			t.Fatalf("unable to write test file: %+v", err)
+		}
+
+		g, err := Load(path)
+		if test.result != (err == nil) {
+			t.Errorf("%s expected success '%+v', got error '%+v'", test.name, test.result, err)
+			continue
+		}
+		if !test.result {
+			continue
+		}
+
+		// Confirm the household members were linked.
+		if members := g.Members(154); len(members) != 2 {
+			t.Errorf("%s expected 2 household members, got '%+v'", test.name, members)
+		}
+		if name, ok := g.Group(426); !ok || name != "business-1" {
+			t.Errorf("%s expected customer 426 in business-1, got '%s'", test.name, name)
+		}
+		if _, ok := g.Group(1); ok {
+			t.Errorf("%s expected customer 1 not to be in a group", test.name)
+		}
+	}
+}
+
+func TestReadCSV(t *testing.T) {
+	if _, err := ReadCSV(strings.NewReader("customer_id,group\nabc,household-1\n")); err == nil {
+		t.Error("expected invalid customer id to fail")
+	}
+
+	// A nil group mapping has no groups.
+	var g *Groups
+	if members := g.Members(1); members != nil {
+		t.Errorf("expected no members, got '%+v'", members)
+	}
+}
@@ -2,14 +2,15 @@ package main
 
 import (
 	"fmt"
+	"os"
 
 	"github.com/nkarpenko/koho-transaction/cmd"
 )
 
 func main() {
-	// Execute main root cobra command.
+	// Execute main root cobra command, exiting with a code matching any error.
 	if err := cmd.RootCmd().Execute(); err != nil {
-		fmt.Printf("failed to initialize application: %s\n", err.Error())
-		return
+		fmt.Fprintf(os.Stderr, "error: %s\n", err.Error())
+		os.Exit(cmd.ExitCode(err))
 	}
 }
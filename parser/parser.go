@@ -4,77 +4,194 @@ package parser
 
 import (
 	"bufio"
-	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
 
-	"github.com/nkarpenko/koho-transaction/conf"
 	txmodel "github.com/nkarpenko/koho-transaction/common/model"
+	"github.com/nkarpenko/koho-transaction/common/stream"
+	"github.com/nkarpenko/koho-transaction/conf"
 )
 
+// Stdin is the input path used to read transactions from standard input.
+const Stdin = stream.Stdio
+
 // Parser interface contains methods required to parsing input files.
 type Parser interface {
 	ParseFile() (*[]txmodel.Transaction, error)
+	Rejected() []*LineError
 }
 
 type parser struct {
-	input string
+	inputs   []string
+	stdin    io.Reader
+	decoder  *txmodel.Decoder
+	rejected []*LineError
+}
+
+// LineError describes an input record that could not be parsed.
+type LineError struct {
+	Source string
+	Line   int
+	Err    error
+}
+
+// Error implements the error interface.
+func (e *LineError) Error() string {
+	return fmt.Sprintf("%s:%d: %v", e.Source, e.Line, e.Err)
 }
 
-// ParseFile method parses files with a collection of JSON objects
-// and returns a slice of transaction structs.
+// ParseFile method parses every configured input source (files, glob patterns
+// or stdin) with a collection of JSON objects and returns a slice of
+// transaction structs merged in timestamp order.
 func (p *parser) ParseFile() (*[]txmodel.Transaction, error) {
 	var txs []txmodel.Transaction
+	p.rejected = nil
 
-	// Confirm input file path exists in config.
-	if p.input == "" {
+	// Confirm at least one input path exists in config.
+	if len(p.inputs) == 0 {
 		return nil, errors.New("invalid input file path supplied in config")
 	}
 
-	// Try and open the input file.
-	file, err := os.Open(p.input)
+	// Expand any glob patterns into the final list of sources.
+	sources, err := p.sources()
 	if err != nil {
 		return nil, err
 	}
-	defer file.Close()
 
-	// Start reading from the file with a reader.
-	reader := bufio.NewReader(file)
+	// Parse each source and add its transactions to the main list.
+	for _, source := range sources {
+		parsed, err := p.parseSource(source)
+		if err != nil {
+			return nil, err
+		}
+		txs = append(txs, parsed...)
+	}
 
-	// Loop through file line by line to
-	var line string
-	for {
+	// Merge multiple sources by transaction time. The sort is stable so the
+	// original order of transactions sharing a timestamp is kept.
+	if len(sources) > 1 {
+		sort.SliceStable(txs, func(i, j int) bool {
+			return txs[i].Time.Before(txs[j].Time)
+		})
+	}
 
-		// Get the new line.
-		line, err = reader.ReadString('\n')
-		if err != nil && err != io.EOF {
-			break
+	// Successful input file parse.
+	return &txs, nil
+}
+
+// sources expands the configured inputs into a list of file paths. Patterns
+// that match nothing are kept as-is so opening them reports a clear error.
+func (p *parser) sources() ([]string, error) {
+	var sources []string
+
+	for _, input := range p.inputs {
+
+		// Stdin and plain paths don't need any expansion.
+		if input == Stdin || !strings.ContainsAny(input, "*?[") {
+			sources = append(sources, input)
+			continue
+		}
+
+		// Expand the glob pattern in lexical order.
+		matches, err := filepath.Glob(input)
+		if err != nil {
+			return nil, fmt.Errorf("invalid input pattern %q: %v", input, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("input pattern %q did not match any files", input)
 		}
+		sources = append(sources, matches...)
+	}
 
-		// Unmarshal string to transaction model.
-		tx := &txmodel.Transaction{}
-		err := json.Unmarshal([]byte(line), tx)
+	return sources, nil
+}
+
+// parseSource opens a single input source, decompressing it if required,
+// and parses its transactions.
+func (p *parser) parseSource(source string) ([]txmodel.Transaction, error) {
+	var r io.Reader = p.stdin
+
+	// Try and open the input file unless reading from stdin.
+	if source != Stdin {
+		file, err := os.Open(source)
 		if err != nil {
-			break
+			return nil, err
 		}
+		defer file.Close()
+		r = file
+	}
 
-		// Add transaction to the main list of transactions
-		txs = append(txs, *tx)
+	// Transparently decompress gzip and zstd input.
+	dr, err := stream.NewReader(r, source)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", source, err)
 	}
+	defer dr.Close()
 
-	// If error was end of file then it was expected.
-	if err != io.EOF {
-		return nil, err
+	return p.parse(dr, source)
+}
+
+// parse reads a stream of line delimited JSON transactions. In strict mode
+// records that fail to decode are rejected and skipped, otherwise the first
+// failure stops parsing.
+func (p *parser) parse(r io.Reader, source string) ([]txmodel.Transaction, error) {
+	var txs []txmodel.Transaction
+
+	// Start reading from the source with a reader.
+	reader := bufio.NewReader(r)
+
+	// Loop through the source line by line.
+	for n := 1; ; n++ {
+
+		// Get the new line.
+		line, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+
+		// Skip blank lines such as the trailing new line of a file.
+		if strings.TrimSpace(line) != "" {
+
+			// Decode string to transaction model.
+			tx, decErr := p.decoder.Decode([]byte(line))
+			if decErr != nil {
+				lineErr := &LineError{Source: source, Line: n, Err: decErr}
+				if !p.decoder.Strict {
+					return nil, lineErr
+				}
+				p.rejected = append(p.rejected, lineErr)
+			} else {
+
+				// Add transaction to the main list of transactions
+				txs = append(txs, *tx)
+			}
+		}
+
+		// If error was end of file then it was expected.
+		if err == io.EOF {
+			return txs, nil
+		}
 	}
+}
 
-	// Successful input file parse.
-	return &txs, nil
+// Rejected returns the records skipped by the last strict mode parse.
+func (p *parser) Rejected() []*LineError {
+	return p.rejected
 }
 
 // New parser instance initialization.
 func New(c *conf.Config) Parser {
 	return &parser{
-		input: c.InputFile,
+		inputs: c.Inputs(),
+		stdin:  os.Stdin,
+		decoder: &txmodel.Decoder{
+			Strict:  c.Strict,
+			Layouts: c.TimeLayouts,
+		},
 	}
 }
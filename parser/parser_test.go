@@ -1,6 +1,9 @@
 package parser
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/nkarpenko/koho-transaction/common/model"
@@ -41,18 +44,132 @@ func TestParseFile(t *testing.T) {
 				},
 			},
 		},
+		{
+			result: true,
+			config: &conf.Config{
+				Name:       "Test Conf 3",
+				InputFiles: []string{"../input.txt", "../input.txt"},
+			},
+		},
+		{
+			result: true,
+			config: &conf.Config{
+				Name:       "Test Conf 4",
+				InputFiles: []string{"../input.*"},
+			},
+		},
+		{
+			result: false,
+			config: &conf.Config{
+				Name:       "Test Conf 5",
+				InputFiles: []string{"../no_match_*.txt"},
+			},
+		},
 	}
 
 	// Run test cases.
 	for _, test := range tests {
 		p := New(test.config)
 		_, err := p.ParseFile()
+		if err == nil && !test.result {
+			t.Error("expected file parse to fail")
+		}
 		if err != nil && test.result {
 			t.Errorf("failed to parse file: %+v", err)
 		}
 	}
 }
 
+func TestParseFileMerge(t *testing.T) {
+
+	// Write two daily files whose transactions interleave in time.
+	dir := t.TempDir()
+	files := map[string]string{
+		"day1.txt": `{"id":"1","customer_id":"1","load_amount":"$1.00","time":"2000-01-01T00:00:00Z"}
+{"id":"3","customer_id":"1","load_amount":"$3.00","time":"2000-01-01T02:00:00Z"}
+`,
+		"day2.txt": `{"id":"2","customer_id":"2","load_amount":"$2.00","time":"2000-01-01T01:00:00Z"}`,
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0644); err != nil {
+			t.Fatalf("unable to write test file: %+v", err)
+		}
+	}
+
+	// Parse both files through a glob pattern.
+	p := New(&conf.Config{InputFiles: []string{filepath.Join(dir, "*.txt")}})
+	txs, err := p.ParseFile()
+	if err != nil {
+		t.Fatalf("failed to parse files: %+v", err)
+	}
+
+	// Confirm the transactions were merged in timestamp order.
+	for i, tx := range *txs {
+		if tx.ID != i+1 {
+			t.Errorf("expected transaction id '%d' at position %d, got '%d'", i+1, i, tx.ID)
+		}
+	}
+}
+
+func TestParseStdin(t *testing.T) {
+
+	// Read a single transaction from a fake stdin.
+	p := &parser{
+		inputs:  []string{Stdin},
+		stdin:   strings.NewReader(`{"id":"1","customer_id":"1","load_amount":"$1.00","time":"2000-01-01T00:00:00Z"}`),
+		decoder: &model.Decoder{},
+	}
+
+	txs, err := p.ParseFile()
+	if err != nil {
+		t.Fatalf("failed to parse stdin: %+v", err)
+	}
+	if len(*txs) != 1 {
+		t.Errorf("expected 1 transaction from stdin, got %d", len(*txs))
+	}
+}
+
+func TestParseStrict(t *testing.T) {
+
+	// Mix valid records with records violating the schema.
+	input := `{"id":"1","customer_id":"1","load_amount":"$1.00","time":"2000-01-01T00:00:00Z"}
+{"id":2,"customer_id":"1","load_amount":"$1.00","time":"2000-01-01T00:00:00Z"}
+{"id":"3","customer_id":"1","load_amount":"","time":"2000-01-01T00:00:00Z"}
+{"id":"4","customer_id":"1","load_amount":"$1.00","time":"2000-01-01T00:00:00Z","memo":"x"}
+{"id":"5","customer_id":"1","load_amount":"$1.00","time":"2000-01-01T00:00:00Z"}
+`
+
+	// Strict mode skips the bad records and keeps parsing.
+	p := &parser{
+		inputs:  []string{Stdin},
+		stdin:   strings.NewReader(input),
+		decoder: &model.Decoder{Strict: true},
+	}
+	txs, err := p.ParseFile()
+	if err != nil {
+		t.Fatalf("failed to parse strict input: %+v", err)
+	}
+	if len(*txs) != 2 {
+		t.Errorf("expected 2 valid transactions, got %d", len(*txs))
+	}
+	if len(p.Rejected()) != 3 {
+		t.Fatalf("expected 3 rejected records, got %d", len(p.Rejected()))
+	}
+	if line := p.Rejected()[0].Line; line != 2 {
+		t.Errorf("expected first rejected record on line 2, got %d", line)
+	}
+
+	// Lenient mode fails on the empty load amount rather than panicking.
+	p = &parser{
+		inputs:  []string{Stdin},
+		stdin:   strings.NewReader(input),
+		decoder: &model.Decoder{},
+	}
+	if _, err := p.ParseFile(); err == nil {
+		t.Error("expected lenient parse of an empty load amount to fail")
+	}
+}
+
 func TestNew(t *testing.T) {
 
 	// Initialize test cases.
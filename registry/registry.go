@@ -0,0 +1,197 @@
+// Package registry contains the customer profile registry holding each
+// customer's account status, KYC level and tier.
+package registry
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/spf13/viper"
+)
+
+// Account statuses.
+const (
+	StatusActive = "active"
+	StatusFrozen = "frozen"
+	StatusClosed = "closed"
+)
+
+// Profile struct holds a single customer's registry details.
+type Profile struct {
+	CustomerID int    `mapstructure:"customer_id"`
+	Status     string `mapstructure:"status"`
+	KYCLevel   string `mapstructure:"kyc_level"`
+	Tier       string `mapstructure:"tier"`
+}
+
+// Registry struct holds every known customer profile. It is safe for
+// concurrent use so it can be reloaded while transactions are validated.
+type Registry struct {
+	path     string
+	mu       sync.RWMutex
+	profiles map[int]Profile
+}
+
+// Profile method returns the customer's profile, if the customer is known.
+func (r *Registry) Profile(customerID int) (Profile, bool) {
+	if r == nil {
+		return Profile{}, false
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	p, ok := r.profiles[customerID]
+	return p, ok
+}
+
+// Reload method re-reads the registry file it was loaded from. The current
+// profiles are kept if the file can't be read.
+func (r *Registry) Reload() error {
+	if r.path == "" {
+		return errors.New("registry was not loaded from a file")
+	}
+
+	profiles, err := read(r.path)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.profiles = profiles
+	r.mu.Unlock()
+
+	return nil
+}
+
+// Load reads the customer registry from a YAML or CSV file, picked by the file
+// extension.
+//
+// YAML files hold a list of customers:
+//
+//	customers:
+//	  - customer_id: 528
+//	    status: active
+//	    kyc_level: full
+//	    tier: gold
+//
+// CSV files have a "customer_id,status,kyc_level,tier" header.
+func Load(path string) (*Registry, error) {
+	profiles, err := read(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Registry{path: path, profiles: profiles}, nil
+}
+
+// read loads the profiles from a registry file.
+func read(path string) (map[int]Profile, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+
+		return readCSV(file)
+	case ".yml", ".yaml":
+		return readYAML(path)
+	}
+
+	return nil, fmt.Errorf("unsupported registry file type %q", filepath.Ext(path))
+}
+
+// readYAML reads the profiles from a YAML file.
+func readYAML(path string) (map[int]Profile, error) {
+	var file struct {
+		Customers []Profile `mapstructure:"customers"`
+	}
+
+	// Use a separate viper instance so the app config isn't touched.
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, err
+	}
+	if err := v.Unmarshal(&file); err != nil {
+		return nil, err
+	}
+
+	return index(file.Customers)
+}
+
+// readCSV reads the profiles from CSV data.
+func readCSV(r io.Reader) (map[int]Profile, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, errors.New("registry file is empty")
+	}
+
+	// Convert each record after the header into a profile.
+	var profiles []Profile
+	for i, record := range records[1:] {
+		if len(record) != 4 {
+			return nil, fmt.Errorf("registry line %d: expected customer_id, status, kyc_level and tier", i+2)
+		}
+
+		customerID, err := strconv.Atoi(strings.TrimSpace(record[0]))
+		if err != nil {
+			return nil, fmt.Errorf("registry line %d: invalid customer id %q", i+2, record[0])
+		}
+		profiles = append(profiles, Profile{
+			CustomerID: customerID,
+			Status:     strings.TrimSpace(record[1]),
+			KYCLevel:   strings.TrimSpace(record[2]),
+			Tier:       strings.TrimSpace(record[3]),
+		})
+	}
+
+	return index(profiles)
+}
+
+// index validates the profiles and maps them by customer id.
+func index(profiles []Profile) (map[int]Profile, error) {
+	m := make(map[int]Profile, len(profiles))
+
+	for _, p := range profiles {
+
+		// Customers are active unless stated otherwise.
+		p.Status = strings.ToLower(p.Status)
+		switch p.Status {
+		case "":
+			p.Status = StatusActive
+		case StatusActive, StatusFrozen, StatusClosed:
+		default:
+			return nil, fmt.Errorf("customer %d has unknown status %q", p.CustomerID, p.Status)
+		}
+
+		if _, ok := m[p.CustomerID]; ok {
+			return nil, fmt.Errorf("customer %d is listed more than once", p.CustomerID)
+		}
+		m[p.CustomerID] = p
+	}
+
+	return m, nil
+}
+
+// New registry instance from a list of profiles.
+func New(profiles ...Profile) (*Registry, error) {
+	m, err := index(profiles)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Registry{profiles: m}, nil
+}
@@ -0,0 +1,111 @@
+package registry
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type test struct {
+	result bool
+	name   string
+	data   string
+}
+
+func TestLoad(t *testing.T) {
+
+	// Initialize test cases.
+	tests := []test{
+		{
+			result: true,
+			name:   "registry.yml",
+			data:   "customers:\n  - customer_id: 528\n    status: frozen\n    kyc_level: full\n    tier: gold\n  - customer_id: 154\n",
+		},
+		{
+			result: true,
+			name:   "registry.csv",
+			data:   "customer_id,status,kyc_level,tier\n528,Frozen,full,gold\n154,,,\n",
+		},
+		{
+			result: false,
+			name:   "registry.csv",
+			data:   "customer_id,status,kyc_level,tier\n528,suspended,full,gold\n",
+		},
+		{
+			result: false,
+			name:   "registry.csv",
+			data:   "customer_id,status,kyc_level,tier\n528,active,full,gold\n528,closed,full,gold\n",
+		},
+		{
+			result: false,
+			name:   "registry.json",
+			data:   "{}",
+		},
+	}
+
+	// Run test cases.
+	for _, test := range tests {
+		path := filepath.Join(t.TempDir(), test.name)
+		if err := os.WriteFile(path, []byte(test.data), 0644); err != nil {
+			t.Fatalf("unable to write test file: %+v", err)
+		}
+
+		r, err := Load(path)
+		if test.result != (err == nil) {
+			t.Errorf("%s expected success '%+v', got error '%+v'", test.name, test.result, err)
+			continue
+		}
+		if !test.result {
+			continue
+		}
+
+		// Confirm the profiles were read and statuses defaulted.
+		if p, ok := r.Profile(528); !ok || p.Status != StatusFrozen || p.KYCLevel != "full" || p.Tier != "gold" {
+			t.Errorf("%s expected frozen full gold profile, got '%+v'", test.name, p)
+		}
+		if p, ok := r.Profile(154); !ok || p.Status != StatusActive {
+			t.Errorf("%s expected active profile, got '%+v'", test.name, p)
+		}
+		if _, ok := r.Profile(1); ok {
+			t.Errorf("%s expected customer 1 not to be in the registry", test.name)
+		}
+	}
+}
+
+func TestReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "registry.csv")
+	write := func(data string) {
+		if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatalf("unable to write test file: %+v", err)
+		}
+	}
+
+	write("customer_id,status,kyc_level,tier\n528,active,basic,standard\n")
+	r, err := Load(path)
+	if err != nil {
+		t.Fatalf("unable to load registry: %+v", err)
+	}
+
+	// The new status is picked up on reload.
+	write("customer_id,status,kyc_level,tier\n528,closed,basic,standard\n")
+	if err := r.Reload(); err != nil {
+		t.Fatalf("unable to reload registry: %+v", err)
+	}
+	if p, _ := r.Profile(528); p.Status != StatusClosed {
+		t.Errorf("expected closed status after reload, got '%s'", p.Status)
+	}
+
+	// A bad file keeps the current profiles.
+	write("customer_id,status,kyc_level,tier\nabc,active,basic,standard\n")
+	if err := r.Reload(); err == nil {
+		t.Error("expected invalid registry reload to fail")
+	}
+	if p, _ := r.Profile(528); p.Status != StatusClosed {
+		t.Errorf("expected closed status to be kept, got '%s'", p.Status)
+	}
+
+	// Registries built in memory can't be reloaded.
+	if r, _ := New(); r.Reload() == nil {
+		t.Error("expected in-memory registry reload to fail")
+	}
+}
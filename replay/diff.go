@@ -0,0 +1,107 @@
+package replay
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/nkarpenko/koho-transaction/common/model"
+)
+
+// Mismatch struct holds a transaction whose decision differs from the
+// expected one.
+type Mismatch struct {
+	Key      Key
+	Expected bool
+	Actual   bool
+}
+
+// Difference struct holds how a run's results differ from the expected
+// results.
+type Difference struct {
+	Missing    []Key // expected but not produced
+	Extra      []Key // produced but not expected
+	Mismatched []Mismatch
+}
+
+// Empty reports whether the results matched the expected results exactly.
+func (d *Difference) Empty() bool {
+	return len(d.Missing) == 0 && len(d.Extra) == 0 && len(d.Mismatched) == 0
+}
+
+// Diff compares results to expected line delimited JSON output, such as the
+// output of an earlier run, matching them by id and customer id.
+func Diff(results []*model.Result, expected io.Reader) (*Difference, error) {
+	diff := &Difference{}
+
+	// Read the expected decisions, keeping their order.
+	var keys []Key
+	want := map[Key]bool{}
+	scanner := bufio.NewScanner(expected)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		key, accepted, err := parseOutput(line)
+		if err != nil {
+			return nil, fmt.Errorf("expected line %d: %v", n, err)
+		}
+		if _, ok := want[key]; ok {
+			return nil, fmt.Errorf("expected line %d: duplicate result for id %d and customer %d", n, key.ID, key.CustomerID)
+		}
+		keys = append(keys, key)
+		want[key] = accepted
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	// Compare each result to its expected decision.
+	got := map[Key]bool{}
+	for _, res := range results {
+		key := KeyOf(res)
+		got[key] = true
+
+		accepted, ok := want[key]
+		switch {
+		case !ok:
+			diff.Extra = append(diff.Extra, key)
+		case accepted != res.Accepted:
+			diff.Mismatched = append(diff.Mismatched, Mismatch{Key: key, Expected: accepted, Actual: res.Accepted})
+		}
+	}
+
+	// Anything expected but not produced is missing.
+	for _, key := range keys {
+		if !got[key] {
+			diff.Missing = append(diff.Missing, key)
+		}
+	}
+
+	return diff, nil
+}
+
+// parseOutput reads the key and decision from a single output line.
+func parseOutput(line string) (Key, bool, error) {
+	var out model.Output
+	if err := json.Unmarshal([]byte(line), &out); err != nil {
+		return Key{}, false, err
+	}
+
+	// Convert the id strings back to ints.
+	id, err := strconv.Atoi(out.ID)
+	if err != nil {
+		return Key{}, false, fmt.Errorf("invalid id %q", out.ID)
+	}
+	customerID, err := strconv.Atoi(out.CustomerID)
+	if err != nil {
+		return Key{}, false, fmt.Errorf("invalid customer id %q", out.CustomerID)
+	}
+
+	return Key{ID: id, CustomerID: customerID}, out.Accepted, nil
+}
@@ -0,0 +1,73 @@
+package replay
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/nkarpenko/koho-transaction/common/model"
+	"github.com/nkarpenko/koho-transaction/conf"
+)
+
+func TestDiff(t *testing.T) {
+	results := []*model.Result{
+		{ID: 1, CustomerID: 1, Accepted: true},
+		{ID: 2, CustomerID: 1, Accepted: false},
+		{ID: 3, CustomerID: 1, Accepted: true},
+	}
+	expected := `{"id":"1","customer_id":"1","accepted":true}
+{"id":"2","customer_id":"1","accepted":true}
+{"id":"4","customer_id":"1","accepted":true}
+`
+
+	diff, err := Diff(results, strings.NewReader(expected))
+	if err != nil {
+		t.Fatalf("unable to diff results: %+v", err)
+	}
+	if diff.Empty() {
+		t.Fatal("expected differences")
+	}
+	if len(diff.Missing) != 1 || diff.Missing[0].ID != 4 {
+		t.Errorf("expected result 4 to be missing, got '%+v'", diff.Missing)
+	}
+	if len(diff.Extra) != 1 || diff.Extra[0].ID != 3 {
+		t.Errorf("expected result 3 to be extra, got '%+v'", diff.Extra)
+	}
+	if len(diff.Mismatched) != 1 || diff.Mismatched[0].Key.ID != 2 || !diff.Mismatched[0].Expected {
+		t.Errorf("expected result 2 to mismatch, got '%+v'", diff.Mismatched)
+	}
+
+	// Invalid and duplicate expected lines are errors.
+	for _, data := range []string{`{"id":"x","customer_id":"1","accepted":true}`, expected + `{"id":"1","customer_id":"1","accepted":true}`} {
+		if _, err := Diff(results, strings.NewReader(data)); err == nil {
+			t.Errorf("expected %q to fail", data)
+		}
+	}
+}
+
+// TestExpectedOutput runs the sample input with the default limits and
+// compares it to the expected output shipped with the repo.
+func TestExpectedOutput(t *testing.T) {
+	results, err := Run(&conf.Config{
+		InputFile: "../input.txt",
+		Limits:    &model.Limits{DailyAmount: 5000, DailyTransactions: 3, WeeklyAmount: 20000},
+	})
+	if err != nil {
+		t.Fatalf("unable to run input: %+v", err)
+	}
+
+	expected, err := os.Open("../expected_output.txt")
+	if err != nil {
+		t.Fatalf("unable to open expected output: %+v", err)
+	}
+	defer expected.Close()
+
+	diff, err := Diff(results, expected)
+	if err != nil {
+		t.Fatalf("unable to diff results: %+v", err)
+	}
+	if !diff.Empty() {
+		t.Errorf("expected output to match, got %d missing, %d extra and %d mismatched",
+			len(diff.Missing), len(diff.Extra), len(diff.Mismatched))
+	}
+}
@@ -0,0 +1,127 @@
+// Package replay contains helpers for running a config over its inputs in a
+// store of its own and comparing the decisions of two runs.
+package replay
+
+import (
+	"io"
+	"sort"
+
+	"github.com/nkarpenko/koho-transaction/common/cache"
+	"github.com/nkarpenko/koho-transaction/common/model"
+	"github.com/nkarpenko/koho-transaction/conf"
+	"github.com/nkarpenko/koho-transaction/parser"
+	"github.com/nkarpenko/koho-transaction/transaction"
+)
+
+// Accepted is the reason counted for accepted transactions.
+const Accepted = "accepted"
+
+// Key struct identifies a transaction across runs.
+type Key struct {
+	ID         int
+	CustomerID int
+}
+
+// Flip struct holds a transaction whose decision differs between two runs.
+type Flip struct {
+	Key Key
+	A   *model.Result
+	B   *model.Result
+}
+
+// Comparison struct holds the differences between two runs over the same
+// input. Dollar amounts are accepted loads and holds in the limit currency.
+type Comparison struct {
+	ReasonsA  map[string]int
+	ReasonsB  map[string]int
+	Flipped   []Flip
+	AcceptedA float64
+	AcceptedB float64
+	Gained    float64 // accepted under B only
+	Lost      float64 // accepted under A only
+}
+
+// Net returns the change in dollars accepted going from A to B.
+func (c *Comparison) Net() float64 {
+	return c.Gained - c.Lost
+}
+
+// Run validates and processes every transaction in the config's inputs in a
+// new store, leaving the main cache untouched. It returns every result in
+// input order, leaving out ignored duplicates.
+func Run(c *conf.Config) ([]*model.Result, error) {
+
+	// Give the run a store of its own.
+	rc := *c
+	rc.Store = cache.New()
+
+	// Parse the inputs.
+	txs, err := parser.New(&rc).ParseFile()
+	if err != nil {
+		return nil, err
+	}
+
+	// Validate and process each transaction in turn, keeping the results.
+	var results []*model.Result
+	t := transaction.New(&rc)
+	t.SetOutput(io.Discard)
+	for i := range *txs {
+		res := t.Validate(&(*txs)[i])
+		if err := t.Process(res); err != nil {
+			return nil, err
+		}
+		if !res.IgnoreMessage {
+			results = append(results, res)
+		}
+	}
+
+	return results, nil
+}
+
+// Compare compares the results of two runs over the same input, counting
+// each run's decisions by reason and finding every flipped decision.
+func Compare(a, b []*model.Result) *Comparison {
+	cmp := &Comparison{ReasonsA: map[string]int{}, ReasonsB: map[string]int{}}
+
+	// Count the decisions of each run.
+	byKey := map[Key]*model.Result{}
+	for _, res := range a {
+		cmp.ReasonsA[reason(res)]++
+		cmp.AcceptedA = cmp.AcceptedA + res.SettledAmount()
+		byKey[KeyOf(res)] = res
+	}
+	for _, res := range b {
+		cmp.ReasonsB[reason(res)]++
+		cmp.AcceptedB = cmp.AcceptedB + res.SettledAmount()
+
+		// Find the transactions accepted under one run only.
+		ra, ok := byKey[KeyOf(res)]
+		if !ok || ra.Accepted == res.Accepted {
+			continue
+		}
+		cmp.Flipped = append(cmp.Flipped, Flip{Key: KeyOf(res), A: ra, B: res})
+		cmp.Gained = cmp.Gained + res.SettledAmount()
+		cmp.Lost = cmp.Lost + ra.SettledAmount()
+	}
+
+	// List the flips in a stable order.
+	sort.SliceStable(cmp.Flipped, func(i, j int) bool {
+		return cmp.Flipped[i].A.Time.Before(cmp.Flipped[j].A.Time)
+	})
+
+	return cmp
+}
+
+// KeyOf returns the key identifying a result.
+func KeyOf(res *model.Result) Key {
+	return Key{ID: res.ID, CustomerID: res.CustomerID}
+}
+
+// reason returns the reason a result is counted under.
+func reason(res *model.Result) string {
+	if res.Accepted {
+		return Accepted
+	}
+
+	return res.Reason
+}
@@ -0,0 +1,59 @@
+package replay
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nkarpenko/koho-transaction/common/cache"
+	"github.com/nkarpenko/koho-transaction/common/model"
+	"github.com/nkarpenko/koho-transaction/conf"
+)
+
+func TestCompare(t *testing.T) {
+	input := filepath.Join(t.TempDir(), "input.txt")
+	data := `{"id":"1","customer_id":"9110","load_amount":"$3000.00","time":"1998-01-01T00:00:00Z"}
+{"id":"2","customer_id":"9110","load_amount":"$1500.00","time":"1998-01-01T01:00:00Z"}
+{"id":"2","customer_id":"9110","load_amount":"$1500.00","time":"1998-01-01T01:00:00Z"}
+{"id":"3","customer_id":"9110","load_amount":"$400.00","time":"1998-01-01T02:00:00Z"}
+`
+	if err := os.WriteFile(input, []byte(data), 0644); err != nil {
+		t.Fatalf("unable to write test file: %+v", err)
+	}
+
+	// Run the same input under a looser and a tighter daily amount.
+	config := func(daily int) *conf.Config {
+		return &conf.Config{
+			InputFiles: []string{input},
+			Limits:     &model.Limits{DailyAmount: daily, DailyTransactions: 3, WeeklyAmount: 20000},
+		}
+	}
+	a, err := Run(config(5000))
+	if err != nil {
+		t.Fatalf("unable to run config a: %+v", err)
+	}
+	b, err := Run(config(4000))
+	if err != nil {
+		t.Fatalf("unable to run config b: %+v", err)
+	}
+
+	// The duplicate is left out and the runs don't touch the main cache.
+	if len(a) != 3 || len(b) != 3 {
+		t.Errorf("expected 3 results per run, got %d and %d", len(a), len(b))
+	}
+	if _, ok := cache.Cache.Customers[9110]; ok {
+		t.Error("expected runs not to write to the main cache")
+	}
+
+	// Only the second load flips.
+	cmp := Compare(a, b)
+	if len(cmp.Flipped) != 1 || cmp.Flipped[0].Key != (Key{ID: 2, CustomerID: 9110}) {
+		t.Fatalf("expected load 2 to flip, got '%+v'", cmp.Flipped)
+	}
+	if cmp.ReasonsA[Accepted] != 3 || cmp.ReasonsB[Accepted] != 2 || cmp.ReasonsB[model.ReasonDailyAmountLimit] != 1 {
+		t.Errorf("unexpected reason counts '%+v' and '%+v'", cmp.ReasonsA, cmp.ReasonsB)
+	}
+	if cmp.AcceptedA != 4900 || cmp.AcceptedB != 3400 || cmp.Lost != 1500 || cmp.Net() != -1500 {
+		t.Errorf("unexpected dollar impact '%+v'", cmp)
+	}
+}
@@ -0,0 +1,110 @@
+// Package server contains the app's HTTP server mode, answering limit queries
+// against the transactions processed so far.
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nkarpenko/koho-transaction/common/model"
+	"github.com/nkarpenko/koho-transaction/conf"
+	"github.com/nkarpenko/koho-transaction/validator"
+)
+
+// Server interface holds the methods required to serve HTTP requests.
+type Server interface {
+	Handler() http.Handler
+	ListenAndServe(addr string) error
+}
+
+// server struct holds the services used to answer requests.
+type server struct {
+	validator validator.Validator
+	decoder   *model.Decoder
+}
+
+// Handler method returns the HTTP handler serving every route.
+func (s *server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/customers/", s.customers)
+	return mux
+}
+
+// Timeouts keep slow or idle clients from holding connections open forever.
+const (
+	readHeaderTimeout = 5 * time.Second
+	readTimeout       = 10 * time.Second
+	writeTimeout      = 10 * time.Second
+	idleTimeout       = 60 * time.Second
+)
+
+// ListenAndServe method serves requests on addr until the server fails.
+func (s *server) ListenAndServe(addr string) error {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: readHeaderTimeout,
+		ReadTimeout:       readTimeout,
+		WriteTimeout:      writeTimeout,
+		IdleTimeout:       idleTimeout,
+	}
+	return srv.ListenAndServe()
+}
+
+// customers handles GET /customers/{id}/usage, returning the customer's limit
+// usage at the optional "at" query time, or now.
+func (s *server) customers(w http.ResponseWriter, r *http.Request) {
+
+	// Split the path into the customer id and resource.
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/customers/"), "/"), "/")
+	if len(parts) != 2 || parts[1] != "usage" {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	// Confirm the customer id is an integer.
+	customerID, err := strconv.Atoi(parts[0])
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "customer id must be an integer")
+		return
+	}
+
+	// Parse the query time with the same formats as the input.
+	at := time.Now().UTC()
+	if q := r.URL.Query().Get("at"); q != "" {
+		if at, err = s.decoder.ParseTime(q); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	writeJSON(w, http.StatusOK, s.validator.Usage(customerID, at))
+}
+
+// writeJSON writes v as a JSON response body.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes a JSON error response.
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
+
+// New server instance.
+func New(c *conf.Config) Server {
+	return &server{
+		validator: validator.New(c),
+		decoder:   &model.Decoder{Layouts: c.TimeLayouts},
+	}
+}
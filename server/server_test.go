@@ -0,0 +1,86 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/nkarpenko/koho-transaction/common/cache"
+	"github.com/nkarpenko/koho-transaction/common/model"
+	"github.com/nkarpenko/koho-transaction/conf"
+)
+
+type test struct {
+	method string
+	path   string
+	status int
+}
+
+func TestUsage(t *testing.T) {
+	day := time.Date(1995, 1, 4, 12, 0, 0, 0, time.UTC)
+	store := cache.New()
+	store.Customers[9080] = []model.Result{
+		{ID: 1, CustomerID: 9080, LoadAmount: 1200, Time: day.Add(-time.Hour), Accepted: true},
+	}
+	store.Customers[9081] = []model.Result{
+		{ID: 1, CustomerID: 9081, LoadAmount: 100, Time: day.Add(-3 * time.Hour), Accepted: true},
+		{ID: 2, CustomerID: 9081, LoadAmount: 100, Time: day.Add(-2 * time.Hour), Accepted: true},
+		{ID: 3, CustomerID: 9081, LoadAmount: 100, Time: day.Add(-time.Hour), Accepted: true},
+	}
+
+	s := New(&conf.Config{
+		Store: store,
+		Limits: &model.Limits{
+			DailyAmount:       5000,
+			DailyTransactions: 3,
+			WeeklyAmount:      20000,
+		},
+	})
+
+	// Initialize test cases.
+	tests := []test{
+		{method: http.MethodGet, path: "/customers/9080/usage?at=1995-01-04T12:00:00Z", status: http.StatusOK},
+		{method: http.MethodPost, path: "/customers/9080/usage", status: http.StatusMethodNotAllowed},
+		{method: http.MethodGet, path: "/customers/abc/usage", status: http.StatusBadRequest},
+		{method: http.MethodGet, path: "/customers/9080/usage?at=yesterday", status: http.StatusBadRequest},
+		{method: http.MethodGet, path: "/customers/9080", status: http.StatusNotFound},
+	}
+
+	// Run test cases.
+	for _, test := range tests {
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, httptest.NewRequest(test.method, test.path, nil))
+		if rec.Code != test.status {
+			t.Errorf("%s %s expected status %d, got %d", test.method, test.path, test.status, rec.Code)
+		}
+	}
+
+	// Confirm the usage body of a successful request.
+	rec := httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tests[0].path, nil))
+	var usage model.Usage
+	if err := json.Unmarshal(rec.Body.Bytes(), &usage); err != nil {
+		t.Fatalf("unable to decode usage: %+v", err)
+	}
+	if daily := usage.Windows[0]; daily.AmountUsed != 1200 || daily.AmountRemaining != 3799.99 || daily.CountRemaining != 2 {
+		t.Errorf("expected $1200 used and $3799.99 and 2 loads left today, got '%+v'", daily)
+	}
+
+	// A used up limit still reports nothing remaining.
+	rec = httptest.NewRecorder()
+	s.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/customers/9081/usage?at=1995-01-04T12:00:00Z", nil))
+	var windows struct {
+		Windows []map[string]interface{} `json:"windows"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &windows); err != nil {
+		t.Fatalf("unable to decode usage: %+v", err)
+	}
+	if remaining, ok := windows.Windows[0]["count_remaining"]; !ok || remaining != float64(0) {
+		t.Errorf("expected 0 daily loads remaining, got '%+v'", windows.Windows[0])
+	}
+	if limit, ok := windows.Windows[2]["amount_limit"]; !ok || limit != float64(0) {
+		t.Errorf("expected a disabled monthly amount limit of 0, got '%+v'", windows.Windows[2])
+	}
+}
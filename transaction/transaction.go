@@ -5,6 +5,8 @@ package transaction
 import (
 	"encoding/json"
 	"fmt"
+	"io"
+	"os"
 	"sort"
 
 	"github.com/nkarpenko/koho-transaction/common/cache"
@@ -17,13 +19,21 @@ import (
 // transactions.
 type Transaction interface {
 	Process(*model.Result) error
+	SetOutput(io.Writer)
 	Validate(*model.Transaction) *model.Result
+
+	// Two-phase load methods.
+	Authorize(*model.Transaction) (*model.Result, error)
+	Capture(*model.Transaction) (*model.Result, error)
+	Void(*model.Transaction) (*model.Result, error)
 }
 
 // transaction struct holds a collection of required interfaces for the
 // transaction service.
 type transaction struct {
 	validator validator.Validator
+	out       io.Writer
+	store     *cache.Store
 }
 
 // Process function stores the transaction data to later check
@@ -35,13 +45,34 @@ func (t *transaction) Process(res *model.Result) error {
 		return nil
 	}
 
+	// Apply accepted reversals, captures and voids to the original load.
+	if res.Accepted {
+		switch res.Type {
+		case model.TypeReversal:
+			t.release(res)
+		case model.TypeCapture:
+			t.capture(res)
+		case model.TypeVoid:
+			t.void(res)
+		}
+	}
+
 	// Add transaction to cache.
-	(*cache.Cache)[res.CustomerID] = append((*cache.Cache)[res.CustomerID], *res)
+	entries := append(t.store.Customers[res.CustomerID], *res)
 
 	// Sort cache key values by date.
-	sort.Slice((*cache.Cache)[res.CustomerID], func(i, j int) bool {
-		return (*cache.Cache)[res.CustomerID][i].Time.After((*cache.Cache)[res.CustomerID][j].Time)
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Time.After(entries[j].Time)
 	})
+	t.store.Customers[res.CustomerID] = entries
+
+	// Add the load to the program's running totals for its day.
+	switch {
+	case res.IsSettled():
+		t.store.Settle(res.Time, res.NetAmount())
+	case res.IsHeld(res.Time):
+		t.store.Hold(res)
+	}
 
 	// Convert the result to a json string.
 	json, err := json.Marshal(res)
@@ -51,12 +82,105 @@ func (t *transaction) Process(res *model.Result) error {
 
 	// Output the final results. At this point we can use the transaction struct
 	// settings to point it to the db, redis, or return it to the user via api.
-	fmt.Println(string(json))
+	if _, err := fmt.Fprintln(t.out, string(json)); err != nil {
+		return err
+	}
 
 	// Successful validation.
 	return nil
 }
 
+// Authorize method validates and records a hold reserving limit capacity for
+// the transaction until it is captured, voided or expires.
+func (t *transaction) Authorize(tx *model.Transaction) (*model.Result, error) {
+	tx.Type = model.TypeAuthorization
+	return t.apply(tx)
+}
+
+// Capture method validates and records the settlement of the pending
+// authorization referenced by the transaction.
+func (t *transaction) Capture(tx *model.Transaction) (*model.Result, error) {
+	tx.Type = model.TypeCapture
+	return t.apply(tx)
+}
+
+// Void method validates and records the cancellation of the pending
+// authorization referenced by the transaction.
+func (t *transaction) Void(tx *model.Transaction) (*model.Result, error) {
+	tx.Type = model.TypeVoid
+	return t.apply(tx)
+}
+
+// apply validates and processes a transaction in one step.
+func (t *transaction) apply(tx *model.Transaction) (*model.Result, error) {
+	res := t.Validate(tx)
+	return res, t.Process(res)
+}
+
+// original returns the cached load or hold referenced by a result.
+func (t *transaction) original(res *model.Result) *model.Result {
+	return t.store.Result(cache.Ref{CustomerID: res.CustomerID, ID: res.ReferenceID})
+}
+
+// release takes an accepted reversal's amount off the original load in the
+// cache so it no longer counts toward the customer's limits.
+func (t *transaction) release(res *model.Result) {
+	load := t.original(res)
+	if load == nil {
+		return
+	}
+
+	// A zero amount reverses whatever is left of the load.
+	if res.LoadAmount == 0 {
+		res.LoadAmount = load.NetAmount()
+	}
+	load.ReversedAmount += res.LoadAmount
+
+	// Pending holds are summed when checked, only settled loads need updating.
+	if load.IsSettled() {
+		t.store.Settle(load.Time, -res.LoadAmount)
+	}
+}
+
+// capture settles the original hold. Capturing less than was authorized
+// releases the remainder of the hold.
+func (t *transaction) capture(res *model.Result) {
+	hold := t.original(res)
+	if hold == nil {
+		return
+	}
+
+	// A zero amount captures the whole hold.
+	if res.LoadAmount == 0 {
+		res.LoadAmount = hold.LoadAmount
+	}
+	hold.ReversedAmount = hold.LoadAmount - res.LoadAmount
+	hold.Pending = false
+
+	// The captured amount now counts as settled on the day of the hold.
+	t.store.Unhold(hold)
+	t.store.Settle(hold.Time, hold.NetAmount())
+}
+
+// void cancels the original hold so it no longer counts toward the
+// customer's limits.
+func (t *transaction) void(res *model.Result) {
+	hold := t.original(res)
+	if hold == nil {
+		return
+	}
+
+	res.LoadAmount = hold.LoadAmount
+	hold.Pending = false
+	hold.Voided = true
+	t.store.Unhold(hold)
+}
+
+// SetOutput sets the destination processed results are written to.
+func (t *transaction) SetOutput(w io.Writer) {
+	t.out = w
+}
+
 // Validate method validates a users transaction to make sure they are within
 // their transaction limits.
 func (t *transaction) Validate(tx *model.Transaction) *model.Result {
@@ -67,7 +191,15 @@ func (t *transaction) Validate(tx *model.Transaction) *model.Result {
 
 // New transaction service instance.
 func New(c *conf.Config) Transaction {
+	// Use the main cache unless the config has its own store.
+	store := c.Store
+	if store == nil {
+		store = cache.Cache
+	}
+
 	return &transaction{
 		validator: validator.New(c),
+		out:       os.Stdout,
+		store:     store,
 	}
 }
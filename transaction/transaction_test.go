@@ -1,8 +1,11 @@
 package transaction
 
 import (
+	"io"
 	"testing"
+	"time"
 
+	"github.com/nkarpenko/koho-transaction/common/cache"
 	"github.com/nkarpenko/koho-transaction/common/model"
 	"github.com/nkarpenko/koho-transaction/conf"
 )
@@ -112,3 +115,141 @@ func TestProcess(t *testing.T) {
 		}
 	}
 }
+
+func TestProcessReversal(t *testing.T) {
+	now := time.Date(1998, 2, 3, 12, 0, 0, 0, time.UTC)
+	store := cache.New()
+	tx := New(&conf.Config{
+		Store: store,
+		Limits: &model.Limits{
+			DailyAmount:       5000,
+			DailyTransactions: 3,
+			WeeklyAmount:      20000,
+		},
+	})
+	tx.SetOutput(io.Discard)
+
+	// Process a load followed by a partial and a full reversal of it.
+	results := []model.Result{
+		{ID: 1, CustomerID: 9101, LoadAmount: 300, Time: now, Accepted: true, Type: model.TypeLoad},
+		{ID: 2, CustomerID: 9101, LoadAmount: 100, Time: now, Accepted: true, Type: model.TypeReversal, ReferenceID: 1},
+		{ID: 3, CustomerID: 9101, Time: now, Accepted: true, Type: model.TypeReversal, ReferenceID: 1},
+	}
+	for i := range results {
+		if err := tx.Process(&results[i]); err != nil {
+			t.Fatalf("unable to process transaction: %+v", err)
+		}
+	}
+
+	// Confirm the whole load amount was released.
+	for _, entry := range store.Customers[9101] {
+		if entry.ID == 1 && entry.NetAmount() != 0 {
+			t.Errorf("expected load to be fully reversed, %v still counts", entry.NetAmount())
+		}
+	}
+}
+
+func TestTwoPhaseLoads(t *testing.T) {
+	now := time.Date(1998, 2, 3, 12, 0, 0, 0, time.UTC)
+	tx := New(&conf.Config{
+		Store: cache.New(),
+		Limits: &model.Limits{
+			DailyAmount:       5000,
+			DailyTransactions: 5,
+			WeeklyAmount:      20000,
+		},
+	})
+	tx.SetOutput(io.Discard)
+
+	// A pending hold reserves limit capacity.
+	res, err := tx.Authorize(&model.Transaction{ID: 1, CustomerID: 9102, LoadAmount: 3000, Time: now.Add(-3 * time.Second)})
+	if err != nil || !res.Accepted {
+		t.Fatalf("expected authorization to be accepted: %+v", err)
+	}
+	res, _ = tx.Authorize(&model.Transaction{ID: 2, CustomerID: 9102, LoadAmount: 2500, Time: now.Add(-2 * time.Second)})
+	if res.Accepted {
+		t.Error("expected pending hold to count against the daily limit")
+	}
+
+	// Voiding the hold releases it.
+	res, _ = tx.Void(&model.Transaction{ID: 3, CustomerID: 9102, ReferenceID: 1, Time: now.Add(-2 * time.Second)})
+	if !res.Accepted {
+		t.Error("expected void of a pending hold to be accepted")
+	}
+	res, _ = tx.Authorize(&model.Transaction{ID: 4, CustomerID: 9102, LoadAmount: 2500, Time: now.Add(-time.Second)})
+	if !res.Accepted {
+		t.Error("expected voided hold to be released from the daily limit")
+	}
+
+	// Negative captures are rejected instead of releasing more than the hold.
+	res, _ = tx.Capture(&model.Transaction{ID: 9, CustomerID: 9102, ReferenceID: 4, LoadAmount: -3000, Time: now})
+	if res.Accepted || res.Reason != model.ReasonInvalidCapture {
+		t.Errorf("expected a negative capture to be rejected as '%s', got '%s'", model.ReasonInvalidCapture, res.Reason)
+	}
+
+	// Capturing part of the hold releases the rest.
+	res, _ = tx.Capture(&model.Transaction{ID: 5, CustomerID: 9102, ReferenceID: 4, LoadAmount: 1000, Time: now})
+	if !res.Accepted {
+		t.Error("expected capture of a pending hold to be accepted")
+	}
+	res, _ = tx.Capture(&model.Transaction{ID: 6, CustomerID: 9102, ReferenceID: 4, Time: now})
+	if res.Accepted {
+		t.Error("expected a second capture of the same hold to be rejected")
+	}
+	res, _ = tx.Void(&model.Transaction{ID: 7, CustomerID: 9102, ReferenceID: 1, Time: now})
+	if res.Accepted {
+		t.Error("expected a void of an already voided hold to be rejected")
+	}
+	res, _ = tx.Authorize(&model.Transaction{ID: 8, CustomerID: 9102, LoadAmount: 3900, Time: now.Add(time.Second)})
+	if !res.Accepted {
+		t.Error("expected the uncaptured remainder to be released from the daily limit")
+	}
+}
+
+func TestProgramTotals(t *testing.T) {
+	day := time.Date(1991, 3, 5, 12, 0, 0, 0, time.UTC)
+	store := cache.New()
+	tx := New(&conf.Config{
+		Limits: &model.Limits{
+			DailyAmount:        5000,
+			DailyTransactions:  3,
+			WeeklyAmount:       20000,
+			ProgramDailyAmount: 1000,
+		},
+		HoldExpiry: time.Hour,
+		Store:      store,
+	})
+	tx.SetOutput(io.Discard)
+
+	// Reversals, captures and voids adjust the totals of the day.
+	steps := []model.Transaction{
+		{ID: 1, CustomerID: 9103, LoadAmount: 300, Time: day, Type: model.TypeLoad},
+		{ID: 2, CustomerID: 9103, LoadAmount: 100, Time: day, Type: model.TypeReversal, ReferenceID: 1},
+		{ID: 1, CustomerID: 9104, LoadAmount: 200, Time: day, Type: model.TypeAuthorization},
+		{ID: 2, CustomerID: 9104, LoadAmount: 150, Time: day, Type: model.TypeCapture, ReferenceID: 1},
+		{ID: 1, CustomerID: 9105, LoadAmount: 500, Time: day, Type: model.TypeAuthorization},
+		{ID: 2, CustomerID: 9105, Time: day, Type: model.TypeVoid, ReferenceID: 1},
+		{ID: 1, CustomerID: 9106, LoadAmount: 400, Time: day, Type: model.TypeAuthorization},
+	}
+	for i := range steps {
+		res := tx.Validate(&steps[i])
+		if err := tx.Process(res); err != nil || !res.Accepted {
+			t.Fatalf("expected step %d to be accepted, got '%s': %+v", i+1, res.Reason, err)
+		}
+	}
+
+	totals := store.Day(day)
+	if totals == nil || totals.Settled != 350 || len(totals.Holds) != 1 {
+		t.Fatalf("expected $350 settled and one pending hold, got %+v", totals)
+	}
+
+	// The pending hold counts toward the program limit until it expires.
+	res := tx.Validate(&model.Transaction{ID: 1, CustomerID: 9107, LoadAmount: 300, Time: day.Add(time.Minute)})
+	if res.Accepted || res.Reason != model.ReasonProgramDailyAmountLimit {
+		t.Errorf("expected program daily limit rejection, got reason '%s'", res.Reason)
+	}
+	res = tx.Validate(&model.Transaction{ID: 2, CustomerID: 9107, LoadAmount: 300, Time: day.Add(2 * time.Hour)})
+	if !res.Accepted {
+		t.Errorf("expected expired hold to be released from the program limit, got reason '%s'", res.Reason)
+	}
+}
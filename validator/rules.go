@@ -0,0 +1,242 @@
+package validator
+
+import (
+	"math"
+
+	"github.com/nkarpenko/koho-transaction/common/model"
+	"github.com/nkarpenko/koho-transaction/registry"
+)
+
+// rule struct holds a single load rule, the reason code and message a load
+// failing it is rejected with and, for window limits, how much of the limit
+// is already used. Amount limits reject a total equal to the limit unless the
+// rule is inclusive.
+type rule struct {
+	reason    string
+	message   string
+	check     func(v *validator, res *model.Result) bool
+	usage     func(v *validator, res *model.Result) (used float64, limit float64)
+	count     bool
+	inclusive bool
+}
+
+// remaining returns the largest amount or count the rule would still accept
+// given what is used of its limit.
+func (r rule) remaining(used, limit float64) float64 {
+	switch {
+	case limit <= 0:
+		return 0
+	case r.count || r.inclusive:
+		return math.Max(0, math.Round((limit-used)*100)/100)
+	}
+
+	return model.AmountRemaining(limit, used)
+}
+
+// loadRules lists every rule a load or authorization must pass, in the order
+// they are checked.
+var loadRules = []rule{
+
+	// Confirm the customer's account is open to new loads.
+	{
+		reason:  model.ReasonUnknownCustomer,
+		message: "customer is not in the registry",
+		check: func(v *validator, res *model.Result) bool {
+			_, ok := v.registry.Profile(res.CustomerID)
+			return v.registry == nil || ok
+		},
+	},
+	{
+		reason:  model.ReasonAccountFrozen,
+		message: "account is frozen",
+		check: func(v *validator, res *model.Result) bool {
+			profile, _ := v.registry.Profile(res.CustomerID)
+			return profile.Status != registry.StatusFrozen
+		},
+	},
+	{
+		reason:  model.ReasonAccountClosed,
+		message: "account is closed",
+		check: func(v *validator, res *model.Result) bool {
+			profile, _ := v.registry.Profile(res.CustomerID)
+			return profile.Status != registry.StatusClosed
+		},
+	},
+
+	// Confirm the amount is positive and within the per-transaction limits.
+	// Invalid amounts don't count as loads.
+	{
+		reason:  model.ReasonInvalidAmount,
+		message: "load amount must be greater than zero",
+		check: func(v *validator, res *model.Result) bool {
+			return v.IsValidAmount(res.LoadAmount)
+		},
+	},
+	{
+		reason:  model.ReasonMinLoadAmount,
+		message: "load amount below minimum",
+		check: func(v *validator, res *model.Result) bool {
+			return v.IsWithinMinLoadAmount(res.CustomerID, res.LoadAmount)
+		},
+	},
+	{
+		reason:  model.ReasonMaxLoadAmount,
+		message: "load amount above maximum",
+		check: func(v *validator, res *model.Result) bool {
+			return v.IsWithinMaxLoadAmount(res.CustomerID, res.LoadAmount)
+		},
+	},
+
+	// Confirm user is within the daily, weekly and monthly load counts.
+	{
+		reason:  model.ReasonDailyLoadLimit,
+		message: "daily load limit exceeded",
+		check: func(v *validator, res *model.Result) bool {
+			return v.IsWithinDailyLoadLimit(res.CustomerID, res.Time)
+		},
+		usage: func(v *validator, res *model.Result) (float64, float64) {
+			used := v.windowCount(res.CustomerID, timeToDayStart(res.Time), res.Time)
+			return float64(used), float64(v.limitsFor(res.CustomerID).DailyTransactions)
+		},
+		count: true,
+	},
+	{
+		reason:  model.ReasonWeeklyLoadLimit,
+		message: "weekly load limit exceeded",
+		check: func(v *validator, res *model.Result) bool {
+			return v.IsWithinWeeklyLoadLimit(res.CustomerID, res.Time)
+		},
+		usage: func(v *validator, res *model.Result) (float64, float64) {
+			used := v.windowCount(res.CustomerID, timeToWeekStart(res.Time), res.Time)
+			return float64(used), float64(v.limitsFor(res.CustomerID).WeeklyTransactions)
+		},
+		count: true,
+	},
+	{
+		reason:  model.ReasonMonthlyLoadLimit,
+		message: "monthly load limit exceeded",
+		check: func(v *validator, res *model.Result) bool {
+			return v.IsWithinMonthlyLoadLimit(res.CustomerID, res.Time)
+		},
+		usage: func(v *validator, res *model.Result) (float64, float64) {
+			used := v.windowCount(res.CustomerID, timeToMonthStart(res.Time), res.Time)
+			return float64(used), float64(v.limitsFor(res.CustomerID).MonthlyTransactions)
+		},
+		count: true,
+	},
+
+	// Confirm the user's loads aren't too close together.
+	{
+		reason:  model.ReasonMinLoadGap,
+		message: "minimum gap between loads not met",
+		check: func(v *validator, res *model.Result) bool {
+			return v.IsAfterMinLoadGap(res.CustomerID, res.Time)
+		},
+	},
+	{
+		reason:  model.ReasonVelocityLimit,
+		message: "velocity limit exceeded",
+		check: func(v *validator, res *model.Result) bool {
+			return v.IsWithinVelocityLimit(res.CustomerID, res.Time)
+		},
+	},
+
+	// Confirm user is within the daily and weekly load amounts.
+	{
+		reason:  model.ReasonDailyAmountLimit,
+		message: "daily amount limit exceeded",
+		check: func(v *validator, res *model.Result) bool {
+			return v.IsWithinDailyAmountLimit(res.CustomerID, res.Time, res.LoadAmount)
+		},
+		usage: func(v *validator, res *model.Result) (float64, float64) {
+			used := v.windowAmount(res.CustomerID, timeToDayStart(res.Time), res.Time)
+			return used, float64(v.limitsFor(res.CustomerID).DailyAmount)
+		},
+	},
+	{
+		reason:  model.ReasonWeeklyAmountLimit,
+		message: "weekly amount limit exceeded",
+		check: func(v *validator, res *model.Result) bool {
+			return v.IsWithinWeeklyAmountLimit(res.CustomerID, res.Time, res.LoadAmount)
+		},
+		usage: func(v *validator, res *model.Result) (float64, float64) {
+			used := v.windowAmount(res.CustomerID, timeToWeekStart(res.Time), res.Time)
+			return used, float64(v.limitsFor(res.CustomerID).WeeklyAmount)
+		},
+	},
+
+	// Confirm the load doesn't push the user's balance over the ceiling.
+	{
+		reason:  model.ReasonMaxBalance,
+		message: "maximum account balance exceeded",
+		check: func(v *validator, res *model.Result) bool {
+			return v.IsWithinMaxBalance(res.CustomerID, res.Time, res.LoadAmount)
+		},
+		usage: func(v *validator, res *model.Result) (float64, float64) {
+			return v.balance(res.CustomerID, res.Time), v.limitsFor(res.CustomerID).MaxBalance
+		},
+		inclusive: true,
+	},
+
+	// Confirm the customer's linked-account group is within its shared limits.
+	{
+		reason:  model.ReasonGroupDailyAmountLimit,
+		message: "group daily amount limit exceeded",
+		check: func(v *validator, res *model.Result) bool {
+			return v.IsWithinGroupDailyAmountLimit(res.CustomerID, res.Time, res.LoadAmount)
+		},
+		usage: func(v *validator, res *model.Result) (float64, float64) {
+			members := v.groups.Members(res.CustomerID)
+			if len(members) == 0 {
+				return 0, 0
+			}
+			limits := v.limitsFor(res.CustomerID)
+			limit := limits.GroupDailyAmount
+			if limit <= 0 {
+				limit = limits.DailyAmount
+			}
+			return v.groupAmount(members, timeToDayStart(res.Time), res.Time), float64(limit)
+		},
+	},
+	{
+		reason:  model.ReasonGroupWeeklyAmountLimit,
+		message: "group weekly amount limit exceeded",
+		check: func(v *validator, res *model.Result) bool {
+			return v.IsWithinGroupWeeklyAmountLimit(res.CustomerID, res.Time, res.LoadAmount)
+		},
+		usage: func(v *validator, res *model.Result) (float64, float64) {
+			members := v.groups.Members(res.CustomerID)
+			if len(members) == 0 {
+				return 0, 0
+			}
+			limits := v.limitsFor(res.CustomerID)
+			limit := limits.GroupWeeklyAmount
+			if limit <= 0 {
+				limit = limits.WeeklyAmount
+			}
+			return v.groupAmount(members, timeToWeekStart(res.Time), res.Time), float64(limit)
+		},
+	},
+
+	// Confirm the whole program is within its daily and weekly liquidity caps.
+	{
+		reason:  model.ReasonProgramDailyAmountLimit,
+		message: "program daily amount limit exceeded",
+		check: func(v *validator, res *model.Result) bool {
+			return v.IsWithinProgramDailyAmountLimit(res.Time, res.LoadAmount)
+		},
+		usage: func(v *validator, res *model.Result) (float64, float64) {
+			return v.programAmount(res.Time, res.Time), float64(v.limits.ProgramDailyAmount)
+		},
+	},
+	{
+		reason:  model.ReasonProgramWeeklyAmountLimit,
+		message: "program weekly amount limit exceeded",
+		check: func(v *validator, res *model.Result) bool {
+			return v.IsWithinProgramWeeklyAmountLimit(res.Time, res.LoadAmount)
+		},
+		usage: func(v *validator, res *model.Result) (float64, float64) {
+			return v.programAmount(timeToWeekStart(res.Time), res.Time), float64(v.limits.ProgramWeeklyAmount)
+		},
+	},
+}
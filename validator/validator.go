@@ -3,11 +3,18 @@
 package validator
 
 import (
+	"fmt"
+	"math"
+	"strings"
 	"time"
 
+	"github.com/nkarpenko/koho-transaction/balance"
 	"github.com/nkarpenko/koho-transaction/common/cache"
 	"github.com/nkarpenko/koho-transaction/common/model"
 	"github.com/nkarpenko/koho-transaction/conf"
+	"github.com/nkarpenko/koho-transaction/fx"
+	"github.com/nkarpenko/koho-transaction/group"
+	"github.com/nkarpenko/koho-transaction/registry"
 )
 
 // Validator interface holds a collection of methods to validate any incoming
@@ -15,23 +22,67 @@ import (
 type Validator interface {
 	// Main validation methods.
 	Validate(*model.Transaction) *model.Result
+	Evaluate(*model.Transaction) (*model.Result, []model.Evaluation)
+	Usage(customerID int, at time.Time) *model.Usage
+	Explain(*model.Transaction) *model.Explanation
 
 	// Bool methods.
+	IsCapturable(customerID int, txid int, date time.Time, amount float64) bool
+	IsReversible(customerID int, txid int, date time.Time, amount float64) bool
+	IsVoidable(customerID int, txid int, date time.Time) bool
 	IsUniqueTransactionID(customerID int, txid int) bool
 	IsWithinDailyAmountLimit(customerID int, date time.Time, amount float64) bool
+	IsValidAmount(amount float64) bool
+	IsWithinMinLoadAmount(customerID int, amount float64) bool
+	IsWithinMaxLoadAmount(customerID int, amount float64) bool
 	IsWithinDailyLoadLimit(customerID int, date time.Time) bool
+	IsWithinWeeklyLoadLimit(customerID int, date time.Time) bool
+	IsWithinMonthlyLoadLimit(customerID int, date time.Time) bool
+	IsAfterMinLoadGap(customerID int, date time.Time) bool
+	IsWithinVelocityLimit(customerID int, date time.Time) bool
 	IsWithinWeeklyAmountLimit(customerID int, date time.Time, amount float64) bool
+	IsWithinMaxBalance(customerID int, date time.Time, amount float64) bool
+	IsWithinBalance(customerID int, date time.Time, amount float64) bool
+	IsWithinGroupDailyAmountLimit(customerID int, date time.Time, amount float64) bool
+	IsWithinGroupWeeklyAmountLimit(customerID int, date time.Time, amount float64) bool
+	IsWithinProgramDailyAmountLimit(date time.Time, amount float64) bool
+	IsWithinProgramWeeklyAmountLimit(date time.Time, amount float64) bool
 }
 
 // validator struct holds a collection of config vars required for various
 // validation methods.
 type validator struct {
-	limits *model.Limits
+	limits     *model.Limits
+	holdExpiry time.Duration
+	currency   string
+	rates      *fx.Table
+	groups     *group.Groups
+	registry   *registry.Registry
+	kycLimits  map[string]*model.Limits
+	balances   *balance.Balances
+	verbose    bool
+	store      *cache.Store
 }
 
 // Validate method validates a users transaction to make sure they are within
 // their transaction limits supplied in the configuration.
 func (v *validator) Validate(tx *model.Transaction) *model.Result {
+	res, _ := v.evaluate(tx, false)
+	return res
+}
+
+// Evaluate method validates a transaction like Validate but runs every load
+// rule instead of stopping at the first failure, returning the outcome of each
+// rule. Like Validate nothing is recorded, so it can be used to check
+// hypothetical loads.
+func (v *validator) Evaluate(tx *model.Transaction) (*model.Result, []model.Evaluation) {
+	return v.evaluate(tx, true)
+}
+
+// evaluate validates a transaction, running every load rule when all is set.
+// Transactions that aren't loads or authorizations have no rule evaluations.
+func (v *validator) evaluate(tx *model.Transaction, all bool) (*model.Result, []model.Evaluation) {
+	var evals []model.Evaluation
 
 	// Init new result transaction model.
 	var res = &model.Result{}
@@ -41,6 +92,9 @@ func (v *validator) Validate(tx *model.Transaction) *model.Result {
 	res.CustomerID = tx.CustomerID
 	res.LoadAmount = tx.LoadAmount
 	res.Time = tx.Time
+	res.Type = tx.Type
+	res.ReferenceID = tx.ReferenceID
+	res.Currency = strings.ToUpper(tx.Currency)
 	res.Accepted = true
 	res.IgnoreMessage = false
 
@@ -49,32 +103,202 @@ func (v *validator) Validate(tx *model.Transaction) *model.Result {
 	if res.Accepted = v.IsUniqueTransactionID(res.CustomerID, res.ID); !res.Accepted {
 		// Provide failure Message for debug.
 		res.Message = "transaction id is not unique for customer, ignoring"
+		res.Reason = model.ReasonDuplicateID
 		res.IgnoreMessage = true
-		return res
+		return res, nil
 	}
 
-	// Confirm user is within load limits and return false if they are not.
-	if res.Accepted = v.IsWithinDailyLoadLimit(res.CustomerID, res.Time); !res.Accepted {
-		// Provide failure Message for debug.
-		res.Message = "daily load limit exceeded"
-		return res
+	// Capture the user's limit usage before this transaction for verbose
+	// output.
+	if v.verbose {
+		v.snapshot(res)
 	}
 
-	// Confirm user is within daily load amount limit and return early if not.
-	if res.Accepted = v.IsWithinDailyAmountLimit(res.CustomerID, res.Time, res.LoadAmount); !res.Accepted {
-		// Provide failure Message for debug.
-		res.Message = "daily amount limit exceeded"
-		return res
+	// Convert the amount into the limit currency before any limit checks.
+	if err := v.convert(res); err != nil {
+		res.Accepted = false
+		res.Message = err.Error()
+		res.Reason = model.ReasonFXRateUnavailable
+		return res, nil
 	}
 
-	// Confirm user is within weekly load amount limit and return early if not.
-	if res.Accepted = v.IsWithinWeeklyAmountLimit(res.CustomerID, res.Time, res.LoadAmount); !res.Accepted {
-		// Provide failure Message for debug.
-		res.Message = "weekly amount limit exceeded"
-		return res
+	// Reversals, captures and voids only need to match an earlier load or
+	// hold. They settle or release limit rather than use more of it so the
+	// limit checks don't apply.
+	switch res.Type {
+	case model.TypeReversal:
+		if res.Accepted = v.IsReversible(res.CustomerID, res.ReferenceID, res.Time, res.LoadAmount); !res.Accepted {
+			// Provide failure Message for debug.
+			res.Message = "reversal does not match a reversible load"
+			res.Reason = model.ReasonInvalidReversal
+		}
+		return res, nil
+	case model.TypeCapture:
+		if res.Accepted = v.IsCapturable(res.CustomerID, res.ReferenceID, res.Time, res.LoadAmount); !res.Accepted {
+			// Provide failure Message for debug.
+			res.Message = "capture does not match a pending authorization"
+			res.Reason = model.ReasonInvalidCapture
+		}
+		return res, nil
+	case model.TypeVoid:
+		if res.Accepted = v.IsVoidable(res.CustomerID, res.ReferenceID, res.Time); !res.Accepted {
+			// Provide failure Message for debug.
+			res.Message = "void does not match a pending authorization"
+			res.Reason = model.ReasonInvalidVoid
+		}
+		return res, nil
+	case model.TypeSpend:
+		// Spends lower the balance but use no limit, so only the amount and
+		// the balance are checked.
+		if res.Accepted = v.IsValidAmount(res.LoadAmount); !res.Accepted {
+			// Provide failure Message for debug.
+			res.Message = "spend amount must be greater than zero"
+			res.Reason = model.ReasonInvalidAmount
+		} else if res.Accepted = v.IsWithinBalance(res.CustomerID, res.Time, res.LoadAmount); !res.Accepted {
+			// Provide failure Message for debug.
+			res.Message = "spend amount exceeds balance"
+			res.Reason = model.ReasonInsufficientBalance
+		}
+		return res, nil
+	case model.TypeAuthorization:
+		// Holds reserve limit like a load until they expire.
+		res.Pending = true
+		res.ExpiresAt = res.Time.Add(v.holdExpiry)
 	}
 
-	return res
+	// Run the load rules in order, stopping at the first failure unless every
+	// rule is being evaluated.
+	for _, r := range loadRules {
+		eval := model.Evaluation{Rule: r.reason, Passed: r.check(v, res)}
+		if all && r.usage != nil {
+			eval.Used, eval.Limit = r.usage(v, res)
+			eval.Remaining = r.remaining(eval.Used, eval.Limit)
+			eval.Count = r.count
+		}
+		evals = append(evals, eval)
+
+		if !eval.Passed && res.Accepted {
+			// Provide failure Message for debug.
+			res.Accepted = false
+			res.Message = r.message
+			res.Reason = r.reason
+		}
+		if !res.Accepted && !all {
+			break
+		}
+	}
+
+	return res, evals
+}
+
+// Usage method returns the load amount and count the user has used in the
+// daily, weekly and monthly windows at the given time, along with what is
+// left of each limit.
+func (v *validator) Usage(customerID int, at time.Time) *model.Usage {
+	limits := v.limitsFor(customerID)
+
+	// Build each window from its start and limits.
+	windows := []struct {
+		name        string
+		start       time.Time
+		amountLimit int
+		countLimit  int
+	}{
+		{"daily", timeToDayStart(at), limits.DailyAmount, limits.DailyTransactions},
+		{"weekly", timeToWeekStart(at), limits.WeeklyAmount, limits.WeeklyTransactions},
+		{"monthly", timeToMonthStart(at), 0, limits.MonthlyTransactions},
+	}
+
+	usage := &model.Usage{CustomerID: customerID, At: at}
+	for _, w := range windows {
+		wu := model.WindowUsage{
+			Window:      w.name,
+			AmountUsed:  v.windowAmount(customerID, w.start, at),
+			AmountLimit: float64(w.amountLimit),
+			CountUsed:   v.windowCount(customerID, w.start, at),
+			CountLimit:  w.countLimit,
+		}
+
+		// Nothing is left once a limit is reached. A load of the whole
+		// difference would reach the amount limit, which is rejected.
+		if wu.AmountLimit > 0 {
+			wu.AmountRemaining = model.AmountRemaining(wu.AmountLimit, wu.AmountUsed)
+		}
+		if wu.CountLimit > wu.CountUsed {
+			wu.CountRemaining = wu.CountLimit - wu.CountUsed
+		}
+
+		usage.Windows = append(usage.Windows, wu)
+	}
+
+	return usage
+}
+
+// Explain method evaluates a transaction like Evaluate and adds the daily,
+// weekly and monthly windows its limits were checked against, with every
+// earlier transaction counted in each.
+func (v *validator) Explain(tx *model.Transaction) *model.Explanation {
+	res, evals := v.evaluate(tx, true)
+
+	// Build each window from its start up to the transaction time.
+	windows := []struct {
+		name  string
+		start time.Time
+	}{
+		{"daily", timeToDayStart(res.Time)},
+		{"weekly", timeToWeekStart(res.Time)},
+		{"monthly", timeToMonthStart(res.Time)},
+	}
+
+	exp := &model.Explanation{Result: res, Evaluations: evals}
+	for _, w := range windows {
+		window := model.Window{Name: w.name, Start: w.start, End: res.Time}
+
+		// Collect the entries counted toward the window amount or count, in
+		// the order they were made. The cache is sorted newest first.
+		entries := v.store.Customers[res.CustomerID]
+		for i := len(entries) - 1; i >= 0; i-- {
+			entry := entries[i]
+			if !entry.Time.After(w.start) || !entry.Time.Before(res.Time) {
+				continue
+			}
+
+			we := model.WindowEntry{
+				ID:      entry.ID,
+				Time:    entry.Time,
+				Type:    entry.Type,
+				Amount:  entry.NetAmount(),
+				InSum:   entry.CountsAt(res.Time),
+				InCount: entry.IsLoadAttempt(),
+			}
+			if !we.InSum && !we.InCount {
+				continue
+			}
+			if we.InSum {
+				window.Amount = window.Amount + we.Amount
+			}
+			if we.InCount {
+				window.Count++
+			}
+			window.Entries = append(window.Entries, we)
+		}
+
+		exp.Windows = append(exp.Windows, window)
+	}
+
+	return exp
+}
+
+// snapshot records the user's daily and weekly usage and limits on the result.
+func (v *validator) snapshot(res *model.Result) {
+	limits := v.limitsFor(res.CustomerID)
+
+	res.Verbose = true
+	res.DailyUsed = v.windowAmount(res.CustomerID, timeToDayStart(res.Time), res.Time)
+	res.DailyLimit = float64(limits.DailyAmount)
+	res.WeeklyUsed = v.windowAmount(res.CustomerID, timeToWeekStart(res.Time), res.Time)
+	res.WeeklyLimit = float64(limits.WeeklyAmount)
+	res.DailyCount = v.windowCount(res.CustomerID, timeToDayStart(res.Time), res.Time)
 }
 
 // IsUniqueTransactionID method validates the transtion ID is unique to
@@ -83,7 +307,7 @@ func (v *validator) IsUniqueTransactionID(cid int, txid int) (accepted bool) {
 
 	// Check if cache data already exists for this customer. Return true if the
 	// key doesn't exist since it means it's their first transaction.
-	data, ok := (*cache.Cache)[cid]
+	data, ok := v.store.Customers[cid]
 	if !ok {
 		return true
 	}
@@ -101,31 +325,171 @@ func (v *validator) IsUniqueTransactionID(cid int, txid int) (accepted bool) {
 	return true
 }
 
-// IsWithinDailyAmountLimit validates that the user's daily load amount is
-// within its daily limit specified inside of the config.yml file.
-func (v *validator) IsWithinDailyAmountLimit(customerID int, date time.Time, amount float64) (accepted bool) {
+// limitsFor returns the limits for the customer's KYC level. Customers
+// without a profile, or whose level has no limits configured, use the
+// default limits.
+func (v *validator) limitsFor(customerID int) *model.Limits {
+	if profile, ok := v.registry.Profile(customerID); ok {
+		if limits, ok := v.kycLimits[strings.ToLower(profile.KYCLevel)]; ok {
+			return limits
+		}
+	}
 
-	// Get the cached user transaction data. Ignore if it DNE since we will have
-	//  to add new data to it anyway.
-	data, _ := (*cache.Cache)[customerID]
+	return v.limits
+}
 
-	// Init load amount counter and 24 hour date limit.
-	limit := timeToDayStart(date)
+// convert sets the result's load amount in the limit currency and records the
+// exchange rate used. Reversals, captures and voids without a currency are in
+// the currency of the load they reference and reuse its rate, so they release
+// exactly what the load used.
+func (v *validator) convert(res *model.Result) error {
+	res.OriginalAmount = res.LoadAmount
+	res.FXRate = 1
+
+	// Follow the referenced load's currency and rate.
+	if !res.IsLoad() {
+		if orig := v.lookup(res.CustomerID, res.ReferenceID); orig != nil && orig.Currency != "" {
+			if res.Currency == "" {
+				res.Currency = orig.Currency
+			}
+			if res.Currency == orig.Currency {
+				res.FXRate = orig.FXRate
+				res.LoadAmount = math.Round(res.OriginalAmount*res.FXRate*100) / 100
+				return nil
+			}
+		}
+	}
 
-	// Loop through cache entries to count amount of transaction for the user.
-	for _, entry := range data {
+	// Amounts without a currency are already in the limit currency.
+	if res.Currency == "" || res.Currency == v.currency {
+		res.Currency = v.currency
+		return nil
+	}
+
+	// Look up the rate effective at the transaction time.
+	if v.rates == nil {
+		return fmt.Errorf("no exchange rates configured to convert %s", res.Currency)
+	}
+	rate, err := v.rates.Rate(res.Currency, res.Time)
+	if err != nil {
+		return err
+	}
 
-		// Add if entry was accepted and count if an entry is between 24 hour agos
-		// and now.
-		if entry.Accepted && entry.Time.After(limit) && entry.Time.Before(date) {
+	// Convert to the limit currency, rounded to the cent.
+	res.FXRate = rate
+	res.LoadAmount = math.Round(res.OriginalAmount*rate*100) / 100
+	return nil
+}
 
-			// Increment the total amount.
-			amount = amount + entry.LoadAmount
+// lookup returns the customer's cached result with the given transaction id.
+func (v *validator) lookup(customerID int, txid int) *model.Result {
+	entries := v.store.Customers[customerID]
+	for i := range entries {
+		if entries[i].ID == txid {
+			return &entries[i]
 		}
 	}
 
+	return nil
+}
+
+// IsReversible method validates that the referenced transaction is an earlier
+// accepted load for the same customer with enough of its amount left to
+// reverse. A zero amount reverses whatever remains.
+func (v *validator) IsReversible(customerID int, txid int, date time.Time, amount float64) (accepted bool) {
+
+	// A negative reversal would add to the load instead of releasing it.
+	if amount < 0 {
+		return false
+	}
+
+	// Find the original transaction in the customer's cache.
+	for _, entry := range v.store.Customers[customerID] {
+		if entry.ID != txid {
+			continue
+		}
+
+		// Only settled loads made before the reversal can be reversed.
+		if !entry.IsSettled() || entry.Time.After(date) {
+			return false
+		}
+
+		// The reversal can't release more than is left of the load.
+		remaining := entry.NetAmount()
+		return remaining > 0 && amount <= remaining
+	}
+
+	// The original transaction doesn't exist.
+	return false
+}
+
+// IsCapturable method validates that the referenced transaction is an
+// authorization for the same customer still pending at the capture time, with
+// at least the captured amount held. A zero amount captures the whole hold.
+func (v *validator) IsCapturable(customerID int, txid int, date time.Time, amount float64) (accepted bool) {
+
+	// A negative capture would release more than the hold.
+	if amount < 0 {
+		return false
+	}
+
+	// Find the authorization in the customer's cache.
+	for _, entry := range v.store.Customers[customerID] {
+		if entry.ID == txid && entry.Type == model.TypeAuthorization {
+			return entry.IsHeld(date) && !entry.Time.After(date) && amount <= entry.LoadAmount
+		}
+	}
+
+	// The authorization doesn't exist.
+	return false
+}
+
+// IsVoidable method validates that the referenced transaction is an
+// authorization for the same customer still pending at the void time.
+func (v *validator) IsVoidable(customerID int, txid int, date time.Time) (accepted bool) {
+
+	// Find the authorization in the customer's cache.
+	for _, entry := range v.store.Customers[customerID] {
+		if entry.ID == txid && entry.Type == model.TypeAuthorization {
+			return entry.IsHeld(date) && !entry.Time.After(date)
+		}
+	}
+
+	// The authorization doesn't exist.
+	return false
+}
+
+// IsValidAmount validates that a load amount is greater than zero.
+func (v *validator) IsValidAmount(amount float64) (accepted bool) {
+	return amount > 0
+}
+
+// IsWithinMinLoadAmount validates that a single load amount is at least the
+// minimum load amount specified inside of the config.yml file.
+func (v *validator) IsWithinMinLoadAmount(customerID int, amount float64) (accepted bool) {
+	limits := v.limitsFor(customerID)
+	return limits.MinLoadAmount <= 0 || amount >= limits.MinLoadAmount
+}
+
+// IsWithinMaxLoadAmount validates that a single load amount is at most the
+// maximum load amount specified inside of the config.yml file.
+func (v *validator) IsWithinMaxLoadAmount(customerID int, amount float64) (accepted bool) {
+	limits := v.limitsFor(customerID)
+	return limits.MaxLoadAmount <= 0 || amount <= limits.MaxLoadAmount
+}
+
+// IsWithinDailyAmountLimit validates that the user's daily load amount is
+// within its daily limit specified inside of the config.yml file.
+func (v *validator) IsWithinDailyAmountLimit(customerID int, date time.Time, amount float64) (accepted bool) {
+
+	// Use the limits of the customer's KYC level.
+	limits := v.limitsFor(customerID)
+
+	// Add the user's load amount since the start of the day.
+	amount = amount + v.windowAmount(customerID, timeToDayStart(date), date)
+
 	// Compare total load amount for last day to validator limit.
-	if amount >= float64(v.limits.DailyAmount) {
+	if amount >= float64(limits.DailyAmount) {
 
 		// User has exceeded allowed daily amount.
 		return false
@@ -139,28 +503,111 @@ func (v *validator) IsWithinDailyAmountLimit(customerID int, date time.Time, amo
 // within its daily limit specified inside of the config.yml file.
 func (v *validator) IsWithinDailyLoadLimit(customerID int, date time.Time) (accepted bool) {
 
-	// Check if cache data already exists for this customer.
-	// Return true if it doesn't meaning this is their first transaction.
-	data, ok := (*cache.Cache)[customerID]
-	if !ok {
+	// Use the limits of the customer's KYC level.
+	limits := v.limitsFor(customerID)
+
+	// Compare total count of loads since the start of the day to validator limit.
+	if v.windowCount(customerID, timeToDayStart(date), date) >= limits.DailyTransactions {
+		return false
+	}
+
+	// Successfully validated and accepted.
+	return true
+}
+
+// IsWithinWeeklyLoadLimit validates that the user's weekly transaction count
+// is within its weekly limit specified inside of the config.yml file. The
+// limit is disabled when left at zero.
+func (v *validator) IsWithinWeeklyLoadLimit(customerID int, date time.Time) (accepted bool) {
+
+	// Use the limits of the customer's KYC level.
+	limits := v.limitsFor(customerID)
+
+	// The rule is disabled without a limit.
+	if limits.WeeklyTransactions <= 0 {
 		return true
 	}
 
-	// Init counter and 24 hour date limit.
-	count := 0
-	limit := timeToDayStart(date)
+	// Compare total count of loads since the start of the week to validator limit.
+	if v.windowCount(customerID, timeToWeekStart(date), date) >= limits.WeeklyTransactions {
+		return false
+	}
 
-	// Loop through cache entries to count amount of transaction for the user.
-	for _, entry := range data {
+	// Successfully validated and accepted.
+	return true
+}
+
+// IsWithinMonthlyLoadLimit validates that the user's monthly transaction count
+// is within its monthly limit specified inside of the config.yml file. The
+// limit is disabled when left at zero.
+func (v *validator) IsWithinMonthlyLoadLimit(customerID int, date time.Time) (accepted bool) {
+
+	// Use the limits of the customer's KYC level.
+	limits := v.limitsFor(customerID)
+
+	// The rule is disabled without a limit.
+	if limits.MonthlyTransactions <= 0 {
+		return true
+	}
+
+	// Compare total count of loads since the start of the month to validator limit.
+	if v.windowCount(customerID, timeToMonthStart(date), date) >= limits.MonthlyTransactions {
+		return false
+	}
+
+	// Successfully validated and accepted.
+	return true
+}
+
+// IsAfterMinLoadGap validates that the user's last load attempt was at least
+// the minimum load gap specified inside of the config.yml file ago.
+func (v *validator) IsAfterMinLoadGap(customerID int, date time.Time) (accepted bool) {
+
+	// Use the limits of the customer's KYC level.
+	limits := v.limitsFor(customerID)
+
+	// The rule is disabled without a gap.
+	if limits.MinLoadGap <= 0 {
+		return true
+	}
+
+	// Reject if any load attempt falls within the gap before this one.
+	limit := date.Add(-limits.MinLoadGap)
+	for _, entry := range v.store.Customers[customerID] {
+		if entry.IsLoadAttempt() && entry.Time.After(limit) && !entry.Time.After(date) {
+			return false
+		}
+	}
+
+	// Successfully validated and accepted.
+	return true
+}
 
-		// Add count if an entry is between 24 hour agos and now.
-		if entry.Time.After(limit) && entry.Time.Before(date) {
+// IsWithinVelocityLimit validates that the user's load attempts within the
+// short velocity window are under the velocity limit specified inside of the
+// config.yml file.
+func (v *validator) IsWithinVelocityLimit(customerID int, date time.Time) (accepted bool) {
+
+	// Use the limits of the customer's KYC level.
+	limits := v.limitsFor(customerID)
+
+	// The rule is disabled without a count and window.
+	if limits.VelocityLoads <= 0 || limits.VelocityWindow <= 0 {
+		return true
+	}
+
+	// Count the load attempts within the window, including ones at the exact
+	// same time since bursts are what this rule is meant to catch.
+	count := 0
+	limit := date.Add(-limits.VelocityWindow)
+	for _, entry := range v.store.Customers[customerID] {
+		if entry.IsLoadAttempt() && entry.Time.After(limit) && !entry.Time.After(date) {
 			count++
 		}
 	}
 
-	// Compare total count of loads to validator limit
-	if count >= v.limits.DailyTransactions {
+	// Compare total count of loads to the velocity limit.
+	if count >= limits.VelocityLoads {
 		return false
 	}
 
@@ -172,23 +619,103 @@ func (v *validator) IsWithinDailyLoadLimit(customerID int, date time.Time) (acce
 // within its daily limit specified inside of the config.yml file.
 func (v *validator) IsWithinWeeklyAmountLimit(customerID int, date time.Time, amount float64) (accepted bool) {
 
-	// Get the cached user transaction data.
-	data, _ := (*cache.Cache)[customerID]
+	// Use the limits of the customer's KYC level.
+	limits := v.limitsFor(customerID)
 
-	// Init load amount counter and 1 week date limit (starting monday).
-	limit := timeToWeekStart(date)
+	// Add the user's load amount since the start of the week (monday).
+	amount = amount + v.windowAmount(customerID, timeToWeekStart(date), date)
 
-	// Loop through cache entries to count amount of transaction for the user.
-	for _, entry := range data {
+	// Compare total load amount for last day to validator limit
+	if amount >= float64(limits.WeeklyAmount) {
+		return false
+	}
+
+	// Successfully validated and accepted.
+	return true
+}
+
+// IsWithinMaxBalance validates that the user's running balance plus the load
+// amount is at most the maximum balance specified inside of the config.yml
+// file. The limit is disabled when left at zero.
+func (v *validator) IsWithinMaxBalance(customerID int, date time.Time, amount float64) (accepted bool) {
+
+	// Use the limits of the customer's KYC level.
+	limits := v.limitsFor(customerID)
+
+	// The rule is disabled without a ceiling.
+	if limits.MaxBalance <= 0 {
+		return true
+	}
+
+	// Compare the balance after the load to the ceiling.
+	if v.balance(customerID, date)+amount > limits.MaxBalance {
+		return false
+	}
+
+	// Successfully validated and accepted.
+	return true
+}
+
+// IsWithinBalance validates that a spend amount is at most the user's running
+// balance, so the balance can't go negative.
+func (v *validator) IsWithinBalance(customerID int, date time.Time, amount float64) (accepted bool) {
 
-		// Add count entry was accepted and if an entry is between a wee ago and now.
-		if entry.Accepted && entry.Time.After(limit) && entry.Time.Before(date) {
-			amount = amount + entry.LoadAmount
+	// Compare the spend to the balance before it.
+	if amount > v.balance(customerID, date) {
+		return false
+	}
+
+	// Successfully validated and accepted.
+	return true
+}
+
+// balance returns the user's running balance at date. It starts from the
+// opening balance, adds accepted loads and pending holds net of reversals and
+// takes off accepted spends.
+func (v *validator) balance(customerID int, date time.Time) float64 {
+	amount := v.balances.Opening(customerID)
+
+	// Loop through cache entries made up to date to apply them in turn.
+	for _, entry := range v.store.Customers[customerID] {
+		if entry.Time.After(date) {
+			continue
+		}
+
+		switch {
+		case entry.IsLoad() && entry.CountsAt(date):
+			amount = amount + entry.NetAmount()
+		case entry.Accepted && entry.Type == model.TypeSpend:
+			amount = amount - entry.LoadAmount
 		}
 	}
 
-	// Compare total load amount for last day to validator limit
-	if amount >= float64(v.limits.WeeklyAmount) {
+	return amount
+}
+
+// IsWithinGroupDailyAmountLimit validates that the daily load amount across
+// every customer in the user's linked-account group is within the group daily
+// limit specified inside of the config.yml file. Customers without a group
+// are always accepted.
+func (v *validator) IsWithinGroupDailyAmountLimit(customerID int, date time.Time, amount float64) (accepted bool) {
+
+	// Use the limits of the customer's KYC level.
+	limits := v.limitsFor(customerID)
+
+	// Nothing to check if the customer isn't in a group.
+	members := v.groups.Members(customerID)
+	if len(members) == 0 {
+		return true
+	}
+
+	// Fall back to the customer limit the group shares.
+	limit := limits.GroupDailyAmount
+	if limit <= 0 {
+		limit = limits.DailyAmount
+	}
+
+	// Compare total load amount across the group to the limit.
+	amount = amount + v.groupAmount(members, timeToDayStart(date), date)
+	if amount >= float64(limit) {
 		return false
 	}
 
@@ -196,6 +723,143 @@ func (v *validator) IsWithinWeeklyAmountLimit(customerID int, date time.Time, am
 	return true
 }
 
+// IsWithinGroupWeeklyAmountLimit validates that the weekly load amount across
+// every customer in the user's linked-account group is within the group
+// weekly limit specified inside of the config.yml file. Customers without a
+// group are always accepted.
+func (v *validator) IsWithinGroupWeeklyAmountLimit(customerID int, date time.Time, amount float64) (accepted bool) {
+
+	// Use the limits of the customer's KYC level.
+	limits := v.limitsFor(customerID)
+
+	// Nothing to check if the customer isn't in a group.
+	members := v.groups.Members(customerID)
+	if len(members) == 0 {
+		return true
+	}
+
+	// Fall back to the customer limit the group shares.
+	limit := limits.GroupWeeklyAmount
+	if limit <= 0 {
+		limit = limits.WeeklyAmount
+	}
+
+	// Compare total load amount across the group to the limit.
+	amount = amount + v.groupAmount(members, timeToWeekStart(date), date)
+	if amount >= float64(limit) {
+		return false
+	}
+
+	// Successfully validated and accepted.
+	return true
+}
+
+// groupAmount sums the accepted loads and pending holds of every customer in
+// a group made after start and before date.
+func (v *validator) groupAmount(members []int, start time.Time, date time.Time) float64 {
+	var amount float64
+
+	for _, customerID := range members {
+		amount = amount + v.windowAmount(customerID, start, date)
+	}
+
+	return amount
+}
+
+// IsWithinProgramDailyAmountLimit validates that the daily load amount across
+// all customers is within the program daily limit specified inside of the
+// config.yml file. The limit is disabled when left at zero.
+func (v *validator) IsWithinProgramDailyAmountLimit(date time.Time, amount float64) (accepted bool) {
+
+	// The rule is disabled without a limit.
+	if v.limits.ProgramDailyAmount <= 0 {
+		return true
+	}
+
+	// Compare total load amount across the program to the limit.
+	amount = amount + v.programAmount(date, date)
+	if amount >= float64(v.limits.ProgramDailyAmount) {
+		return false
+	}
+
+	// Successfully validated and accepted.
+	return true
+}
+
+// IsWithinProgramWeeklyAmountLimit validates that the weekly load amount
+// across all customers is within the program weekly limit specified inside of
+// the config.yml file. The limit is disabled when left at zero.
+func (v *validator) IsWithinProgramWeeklyAmountLimit(date time.Time, amount float64) (accepted bool) {
+
+	// The rule is disabled without a limit.
+	if v.limits.ProgramWeeklyAmount <= 0 {
+		return true
+	}
+
+	// Compare total load amount across the program to the limit.
+	amount = amount + v.programAmount(timeToWeekStart(date), date)
+	if amount >= float64(v.limits.ProgramWeeklyAmount) {
+		return false
+	}
+
+	// Successfully validated and accepted.
+	return true
+}
+
+// programAmount sums the accepted loads and pending holds of every customer
+// from the day first falls on up to date, using the running totals kept for
+// each day. Holds only count until they expire.
+func (v *validator) programAmount(first time.Time, date time.Time) float64 {
+	var amount float64
+
+	for day := first; !day.After(date); day = day.AddDate(0, 0, 1) {
+		totals := v.store.Day(day)
+		if totals == nil {
+			continue
+		}
+
+		amount = amount + totals.Settled
+		for _, ref := range totals.Holds {
+			hold := v.store.Result(ref)
+			if hold != nil && hold.IsHeld(date) && hold.Time.Before(date) {
+				amount = amount + hold.NetAmount()
+			}
+		}
+	}
+
+	return amount
+}
+
+// windowAmount sums the user's accepted loads and pending holds made after
+// start and before date. Reversed amounts no longer count.
+func (v *validator) windowAmount(customerID int, start time.Time, date time.Time) float64 {
+	var amount float64
+
+	// Loop through cache entries to add up the amounts in the window.
+	for _, entry := range v.store.Customers[customerID] {
+		if entry.CountsAt(date) && entry.Time.After(start) && entry.Time.Before(date) {
+			amount = amount + entry.NetAmount()
+		}
+	}
+
+	return amount
+}
+
+// windowCount counts the user's load attempts made after start and before
+// date, whether they were accepted or not.
+func (v *validator) windowCount(customerID int, start time.Time, date time.Time) int {
+	count := 0
+
+	// Loop through cache entries to count the loads in the window.
+	for _, entry := range v.store.Customers[customerID] {
+		if entry.IsLoadAttempt() && entry.Time.After(start) && entry.Time.Before(date) {
+			count++
+		}
+	}
+
+	return count
+}
+
 // timeToDayStart helper method returns the start date/time of the specific day
 // given (Midnight -1 second).
 func timeToDayStart(t time.Time) time.Time {
@@ -205,6 +869,15 @@ func timeToDayStart(t time.Time) time.Time {
 	return time.Date(year, month, day, 0, 0, -1, 0, t.Location())
 }
 
+// timeToMonthStart helper method returns the start date/time of the month of
+// the date given (Midnight of the first -1 second).
+func timeToMonthStart(t time.Time) time.Time {
+	year, month, _ := t.Date()
+
+	// End month 1 second before midnight of the first.
+	return time.Date(year, month, 1, 0, 0, -1, 0, t.Location())
+}
+
 // timeToWeekStart helper method returns the date for the start of the week
 // (Monday Midnight)
 func timeToWeekStart(t time.Time) time.Time {
@@ -222,7 +895,42 @@ func timeToWeekStart(t time.Time) time.Time {
 // New Validator instance.
 func New(c *conf.Config) Validator {
 
+	// Fall back to the default hold expiry if none is configured.
+	holdExpiry := c.HoldExpiry
+	if holdExpiry <= 0 {
+		holdExpiry = model.DefaultHoldExpiry
+	}
+
+	// Fall back to the default limit currency if none is configured.
+	currency := strings.ToUpper(c.Currency)
+	if currency == "" {
+		currency = model.DefaultCurrency
+	}
+
+	// Use the main cache unless the config has its own store.
+	store := c.Store
+	if store == nil {
+		store = cache.Cache
+	}
+
+	// Fill in any limit a KYC level leaves unset from the default limits.
+	kycLimits := make(map[string]*model.Limits, len(c.KYCLimits))
+	for level, limits := range c.KYCLimits {
+		if limits != nil {
+			kycLimits[strings.ToLower(level)] = limits.WithDefaults(c.Limits)
+		}
+	}
+
 	return &validator{
-		limits: c.Limits,
+		limits:     c.Limits,
+		holdExpiry: holdExpiry,
+		currency:   currency,
+		rates:      c.Rates,
+		groups:     c.Groups,
+		registry:   c.Registry,
+		kycLimits:  kycLimits,
+		balances:   c.Balances,
+		verbose:    c.Verbose,
+		store:      store,
 	}
 }
@@ -4,8 +4,13 @@ import (
 	"testing"
 	"time"
 
+	"github.com/nkarpenko/koho-transaction/balance"
+	"github.com/nkarpenko/koho-transaction/common/cache"
 	"github.com/nkarpenko/koho-transaction/common/model"
 	"github.com/nkarpenko/koho-transaction/conf"
+	"github.com/nkarpenko/koho-transaction/fx"
+	"github.com/nkarpenko/koho-transaction/group"
+	"github.com/nkarpenko/koho-transaction/registry"
 )
 
 type test struct {
@@ -14,6 +19,7 @@ type test struct {
 	config       *conf.Config
 	txid         int
 	accepted     bool
+	reason       string
 	transactions []model.Transaction
 }
 
@@ -381,3 +387,692 @@ func TestIsWithinWeeklyAmountLimit(t *testing.T) {
 		}
 	}
 }
+
+func TestIsReversible(t *testing.T) {
+	now := time.Date(1998, 2, 3, 12, 0, 0, 0, time.UTC)
+
+	store := cache.New()
+
+	// Seed the store with an accepted and a rejected load.
+	store.Customers[9001] = []model.Result{
+		{ID: 1, CustomerID: 9001, LoadAmount: 100, Time: now.Add(-time.Hour), Accepted: true, Type: model.TypeLoad},
+		{ID: 2, CustomerID: 9001, LoadAmount: 100, Time: now.Add(-time.Hour), Accepted: false, Type: model.TypeLoad},
+		{ID: 3, CustomerID: 9001, LoadAmount: 100, Time: now.Add(-time.Hour), Accepted: true, Type: model.TypeLoad, ReversedAmount: 100},
+	}
+
+	// Initialize test cases.
+	tests := []test{
+		{
+			accepted: true,
+			transactions: []model.Transaction{
+				{ID: 10, CustomerID: 9001, LoadAmount: 40, Time: now, Type: model.TypeReversal, ReferenceID: 1},
+			},
+		},
+		{
+			accepted: false,
+			transactions: []model.Transaction{
+				{ID: 11, CustomerID: 9001, LoadAmount: 150, Time: now, Type: model.TypeReversal, ReferenceID: 1},
+			},
+		},
+		{
+			accepted: false,
+			transactions: []model.Transaction{
+				{ID: 12, CustomerID: 9001, LoadAmount: 50, Time: now, Type: model.TypeReversal, ReferenceID: 2},
+			},
+		},
+		{
+			accepted: false,
+			transactions: []model.Transaction{
+				{ID: 13, CustomerID: 9001, LoadAmount: 50, Time: now, Type: model.TypeReversal, ReferenceID: 3},
+			},
+		},
+		{
+			accepted: false,
+			transactions: []model.Transaction{
+				{ID: 14, CustomerID: 9001, LoadAmount: 50, Time: now, Type: model.TypeReversal, ReferenceID: 99},
+			},
+		},
+		{
+			accepted: false,
+			transactions: []model.Transaction{
+				{ID: 15, CustomerID: 9001, LoadAmount: 50, Time: now.Add(-2 * time.Hour), Type: model.TypeReversal, ReferenceID: 1},
+			},
+		},
+		{
+			accepted: false,
+			transactions: []model.Transaction{
+				{ID: 16, CustomerID: 9001, LoadAmount: -30, Time: now, Type: model.TypeReversal, ReferenceID: 1},
+			},
+		},
+	}
+
+	// Run test cases.
+	v := New(&conf.Config{
+		Store: store,
+		Limits: &model.Limits{
+			DailyAmount:       5000,
+			DailyTransactions: 3,
+			WeeklyAmount:      20000,
+		},
+	})
+	for _, test := range tests {
+		for _, tx := range test.transactions {
+			res := v.Validate(&tx)
+			if test.accepted != res.Accepted {
+				t.Errorf("reversal '%d' expected accepted '%+v', got '%+v'", tx.ID, test.accepted, res.Accepted)
+			}
+		}
+	}
+}
+
+func TestReversalReleasesLimit(t *testing.T) {
+	now := time.Date(1998, 2, 3, 12, 0, 0, 0, time.UTC)
+
+	store := cache.New()
+
+	// Seed the store with a load using most of the daily limit, half of which
+	// has been reversed.
+	store.Customers[9002] = []model.Result{
+		{ID: 1, CustomerID: 9002, LoadAmount: 4000, Time: now.Add(-time.Second), Accepted: true, Type: model.TypeLoad, ReversedAmount: 2000},
+	}
+
+	v := New(&conf.Config{
+		Store: store,
+		Limits: &model.Limits{
+			DailyAmount:       5000,
+			DailyTransactions: 3,
+			WeeklyAmount:      20000,
+		},
+	})
+
+	// Only the unreversed 2000 counts toward the daily total.
+	if !v.IsWithinDailyAmountLimit(9002, now, 2500) {
+		t.Error("expected reversed amount to be released from the daily limit")
+	}
+	if !v.IsWithinWeeklyAmountLimit(9002, now, 2500) {
+		t.Error("expected reversed amount to be released from the weekly limit")
+	}
+	if v.IsWithinDailyAmountLimit(9002, now, 3500) {
+		t.Error("expected unreversed amount to still count toward the daily limit")
+	}
+}
+
+func TestHoldExpiry(t *testing.T) {
+	now := time.Date(1998, 2, 3, 12, 0, 0, 0, time.UTC)
+
+	store := cache.New()
+
+	// Seed the store with a hold that expired a second ago.
+	store.Customers[9003] = []model.Result{
+		{ID: 1, CustomerID: 9003, LoadAmount: 4000, Time: now.Add(-time.Hour), Accepted: true, Type: model.TypeAuthorization, Pending: true, ExpiresAt: now.Add(-time.Second)},
+	}
+
+	v := New(&conf.Config{
+		Store:      store,
+		HoldExpiry: time.Hour,
+		Limits: &model.Limits{
+			DailyAmount:       5000,
+			DailyTransactions: 3,
+			WeeklyAmount:      20000,
+		},
+	})
+
+	// The stale hold no longer counts and can't be captured or voided.
+	if !v.IsWithinDailyAmountLimit(9003, now, 2500) {
+		t.Error("expected expired hold to be released from the daily limit")
+	}
+	if v.IsCapturable(9003, 1, now, 0) {
+		t.Error("expected expired hold not to be capturable")
+	}
+	if v.IsVoidable(9003, 1, now) {
+		t.Error("expected expired hold not to be voidable")
+	}
+
+	// New holds expire after the configured duration.
+	res := v.Validate(&model.Transaction{ID: 2, CustomerID: 9003, LoadAmount: 100, Time: now, Type: model.TypeAuthorization})
+	if !res.Pending || !res.ExpiresAt.Equal(now.Add(time.Hour)) {
+		t.Errorf("expected pending hold expiring at '%v', got '%v'", now.Add(time.Hour), res.ExpiresAt)
+	}
+}
+
+func TestConvert(t *testing.T) {
+	now := time.Date(1998, 2, 3, 12, 0, 0, 0, time.UTC)
+
+	v := New(&conf.Config{
+		Store:    cache.New(),
+		Currency: "CAD",
+		Rates: fx.New(fx.Rate{
+			Date:     now.Add(-24 * time.Hour),
+			Currency: "USD",
+			Rate:     1.35,
+		}),
+		Limits: &model.Limits{
+			DailyAmount:       5000,
+			DailyTransactions: 3,
+			WeeklyAmount:      20000,
+		},
+	})
+
+	// USD amounts are converted into CAD before the limit checks.
+	res := v.Validate(&model.Transaction{ID: 1, CustomerID: 9004, LoadAmount: 3000, Currency: "USD", Time: now})
+	if !res.Accepted || res.LoadAmount != 4050 || res.FXRate != 1.35 || res.OriginalAmount != 3000 {
+		t.Errorf("expected 3000 USD to convert to 4050 CAD at 1.35, got '%+v'", res)
+	}
+	res = v.Validate(&model.Transaction{ID: 2, CustomerID: 9004, LoadAmount: 4000, Currency: "USD", Time: now})
+	if res.Accepted {
+		t.Error("expected converted amount to exceed the daily limit")
+	}
+
+	// Amounts in the limit currency or without one aren't converted.
+	res = v.Validate(&model.Transaction{ID: 3, CustomerID: 9004, LoadAmount: 100, Time: now})
+	if res.LoadAmount != 100 || res.FXRate != 1 || res.Currency != "CAD" {
+		t.Errorf("expected unconverted CAD amount, got '%+v'", res)
+	}
+
+	// Currencies without a rate are rejected.
+	res = v.Validate(&model.Transaction{ID: 4, CustomerID: 9004, LoadAmount: 100, Currency: "EUR", Time: now})
+	if res.Accepted {
+		t.Error("expected currency without a rate to be rejected")
+	}
+}
+
+func TestVelocityLimits(t *testing.T) {
+	now := time.Date(1998, 2, 3, 12, 0, 0, 0, time.UTC)
+
+	store := cache.New()
+
+	// Seed the store with two loads in the last ten minutes.
+	store.Customers[9005] = []model.Result{
+		{ID: 1, CustomerID: 9005, LoadAmount: 10, Time: now.Add(-9 * time.Minute), Accepted: true},
+		{ID: 2, CustomerID: 9005, LoadAmount: 10, Time: now.Add(-5 * time.Minute), Accepted: true},
+	}
+
+	v := New(&conf.Config{
+		Store: store,
+		Limits: &model.Limits{
+			DailyAmount:       5000,
+			DailyTransactions: 5,
+			WeeklyAmount:      20000,
+			VelocityLoads:     2,
+			VelocityWindow:    10 * time.Minute,
+			MinLoadGap:        time.Minute,
+		},
+	})
+
+	// A third load inside the window breaks the velocity limit.
+	res := v.Validate(&model.Transaction{ID: 3, CustomerID: 9005, LoadAmount: 10, Time: now})
+	if res.Accepted || res.Reason != model.ReasonVelocityLimit {
+		t.Errorf("expected velocity limit rejection, got reason '%s'", res.Reason)
+	}
+
+	// Once the first load leaves the window the third is accepted.
+	if !v.IsWithinVelocityLimit(9005, now.Add(90*time.Second)) {
+		t.Error("expected load outside the velocity window to be accepted")
+	}
+
+	// Loads closer together than the minimum gap are rejected.
+	res = v.Validate(&model.Transaction{ID: 4, CustomerID: 9005, LoadAmount: 10, Time: now.Add(-4*time.Minute - 30*time.Second)})
+	if res.Accepted || res.Reason != model.ReasonMinLoadGap {
+		t.Errorf("expected minimum gap rejection, got reason '%s'", res.Reason)
+	}
+	if !v.IsAfterMinLoadGap(9005, now.Add(-4*time.Minute)) {
+		t.Error("expected load a minute after the last one to be accepted")
+	}
+}
+
+func TestLoadAmountLimits(t *testing.T) {
+	now := time.Date(1998, 2, 3, 12, 0, 0, 0, time.UTC)
+
+	v := New(&conf.Config{
+		Store: cache.New(),
+		Limits: &model.Limits{
+			DailyAmount:       5000,
+			DailyTransactions: 3,
+			WeeklyAmount:      20000,
+			MinLoadAmount:     5,
+			MaxLoadAmount:     2500,
+		},
+	})
+
+	// Initialize test cases, each for a new customer.
+	tests := []test{
+		{
+			reason:       model.ReasonInvalidAmount,
+			transactions: []model.Transaction{{ID: 1, CustomerID: 9007, LoadAmount: 0, Time: now}},
+		},
+		{
+			reason:       model.ReasonInvalidAmount,
+			transactions: []model.Transaction{{ID: 1, CustomerID: 9008, LoadAmount: -10, Time: now}},
+		},
+		{
+			reason:       model.ReasonMinLoadAmount,
+			transactions: []model.Transaction{{ID: 1, CustomerID: 9009, LoadAmount: 0.01, Time: now}},
+		},
+		{
+			reason:       model.ReasonMaxLoadAmount,
+			transactions: []model.Transaction{{ID: 1, CustomerID: 9010, LoadAmount: 4999.99, Time: now}},
+		},
+		{
+			accepted:     true,
+			transactions: []model.Transaction{{ID: 1, CustomerID: 9011, LoadAmount: 5, Time: now}},
+		},
+		{
+			accepted:     true,
+			transactions: []model.Transaction{{ID: 1, CustomerID: 9012, LoadAmount: 2500, Time: now}},
+		},
+	}
+
+	// Run test cases.
+	for _, test := range tests {
+		for _, tx := range test.transactions {
+			res := v.Validate(&tx)
+			if res.Reason != test.reason || res.Accepted != test.accepted {
+				t.Errorf("amount %v expected reason '%s', got '%s'", tx.LoadAmount, test.reason, res.Reason)
+			}
+		}
+	}
+}
+
+func TestWeeklyAndMonthlyLoadLimits(t *testing.T) {
+
+	// Seed the store with loads on Monday and Tuesday of the same week and
+	// month, one of them rejected.
+	monday := time.Date(2000, 1, 10, 12, 0, 0, 0, time.UTC)
+	store := cache.New()
+	store.Customers[9020] = []model.Result{
+		{ID: 1, CustomerID: 9020, LoadAmount: 10, Time: time.Date(2000, 1, 3, 12, 0, 0, 0, time.UTC), Accepted: true},
+		{ID: 2, CustomerID: 9020, LoadAmount: 10, Time: monday, Accepted: true},
+		{ID: 3, CustomerID: 9020, LoadAmount: 10, Time: monday.Add(24 * time.Hour), Accepted: false},
+	}
+
+	v := New(&conf.Config{
+		Store: store,
+		Limits: &model.Limits{
+			DailyAmount:         5000,
+			DailyTransactions:   3,
+			WeeklyAmount:        20000,
+			WeeklyTransactions:  2,
+			MonthlyTransactions: 4,
+		},
+	})
+
+	// Two attempts this week reach the weekly count.
+	res := v.Validate(&model.Transaction{ID: 4, CustomerID: 9020, LoadAmount: 10, Time: monday.Add(48 * time.Hour)})
+	if res.Accepted || res.Reason != model.ReasonWeeklyLoadLimit {
+		t.Errorf("expected weekly load limit rejection, got reason '%s'", res.Reason)
+	}
+
+	// The following week only the monthly count applies.
+	nextMonday := monday.Add(7 * 24 * time.Hour)
+	if !v.IsWithinWeeklyLoadLimit(9020, nextMonday.Add(time.Hour)) {
+		t.Error("expected weekly load count to reset on monday")
+	}
+	store.Customers[9020] = append(store.Customers[9020], model.Result{ID: 5, CustomerID: 9020, LoadAmount: 10, Time: nextMonday.Add(time.Hour), Accepted: true})
+	res = v.Validate(&model.Transaction{ID: 6, CustomerID: 9020, LoadAmount: 10, Time: nextMonday.Add(2 * time.Hour)})
+	if res.Accepted || res.Reason != model.ReasonMonthlyLoadLimit {
+		t.Errorf("expected monthly load limit rejection, got reason '%s'", res.Reason)
+	}
+
+	// The monthly count resets on the first of the month.
+	if !v.IsWithinMonthlyLoadLimit(9020, time.Date(2000, 2, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Error("expected monthly load count to reset on the first")
+	}
+}
+
+func TestProgramAmountLimits(t *testing.T) {
+
+	// Seed the store with loads from two customers on the same day.
+	day := time.Date(1990, 1, 3, 12, 0, 0, 0, time.UTC)
+	store := cache.New()
+	store.Customers[9030] = []model.Result{
+		{ID: 1, CustomerID: 9030, LoadAmount: 3000, Time: day, Accepted: true},
+	}
+	store.Customers[9031] = []model.Result{
+		{ID: 1, CustomerID: 9031, LoadAmount: 4000, Time: day, Accepted: true},
+		{ID: 2, CustomerID: 9031, LoadAmount: 9000, Time: day, Accepted: false},
+	}
+	store.Settle(day, 7000)
+
+	v := New(&conf.Config{
+		Store: store,
+		Limits: &model.Limits{
+			DailyAmount:         5000,
+			DailyTransactions:   3,
+			WeeklyAmount:        20000,
+			ProgramDailyAmount:  10000,
+			ProgramWeeklyAmount: 10500,
+		},
+	})
+
+	// The customer is within their own limits but the program is not.
+	res := v.Validate(&model.Transaction{ID: 1, CustomerID: 9032, LoadAmount: 3500, Time: day.Add(time.Hour)})
+	if res.Accepted || res.Reason != model.ReasonProgramDailyAmountLimit {
+		t.Errorf("expected program daily limit rejection, got reason '%s'", res.Reason)
+	}
+
+	// The next day only the weekly program cap applies.
+	res = v.Validate(&model.Transaction{ID: 2, CustomerID: 9032, LoadAmount: 3500, Time: day.Add(24 * time.Hour)})
+	if res.Accepted || res.Reason != model.ReasonProgramWeeklyAmountLimit {
+		t.Errorf("expected program weekly limit rejection, got reason '%s'", res.Reason)
+	}
+	if !v.IsWithinProgramWeeklyAmountLimit(day.Add(24*time.Hour), 3000) {
+		t.Error("expected load within the program weekly limit to be accepted")
+	}
+
+	// The customer cap is reported ahead of the program cap.
+	res = v.Validate(&model.Transaction{ID: 3, CustomerID: 9032, LoadAmount: 5000, Time: day.Add(time.Hour)})
+	if res.Reason != model.ReasonDailyAmountLimit {
+		t.Errorf("expected customer daily limit rejection, got reason '%s'", res.Reason)
+	}
+}
+
+func TestGroupAmountLimits(t *testing.T) {
+	day := time.Date(1991, 1, 2, 12, 0, 0, 0, time.UTC)
+
+	// Link two customers into a household, one of which already loaded.
+	groups := group.New()
+	groups.Add(9040, "household")
+	groups.Add(9041, "household")
+	store := cache.New()
+	store.Customers[9040] = []model.Result{
+		{ID: 1, CustomerID: 9040, LoadAmount: 4000, Time: day, Accepted: true},
+	}
+
+	v := New(&conf.Config{
+		Store:  store,
+		Groups: groups,
+		Limits: &model.Limits{
+			DailyAmount:       5000,
+			DailyTransactions: 3,
+			WeeklyAmount:      20000,
+			GroupWeeklyAmount: 6000,
+		},
+	})
+
+	// The household shares the customer daily amount.
+	res := v.Validate(&model.Transaction{ID: 1, CustomerID: 9041, LoadAmount: 1500, Time: day.Add(time.Hour)})
+	if res.Accepted || res.Reason != model.ReasonGroupDailyAmountLimit {
+		t.Errorf("expected group daily limit rejection, got reason '%s'", res.Reason)
+	}
+
+	// The next day the group weekly amount applies.
+	res = v.Validate(&model.Transaction{ID: 2, CustomerID: 9041, LoadAmount: 2500, Time: day.Add(24 * time.Hour)})
+	if res.Accepted || res.Reason != model.ReasonGroupWeeklyAmountLimit {
+		t.Errorf("expected group weekly limit rejection, got reason '%s'", res.Reason)
+	}
+
+	// Per-customer limits still apply on top of the group.
+	res = v.Validate(&model.Transaction{ID: 3, CustomerID: 9041, LoadAmount: 5000, Time: day.Add(time.Hour)})
+	if res.Reason != model.ReasonDailyAmountLimit {
+		t.Errorf("expected customer daily limit rejection, got reason '%s'", res.Reason)
+	}
+
+	// Customers outside the group are unaffected.
+	if !v.IsWithinGroupDailyAmountLimit(9042, day.Add(time.Hour), 4500) {
+		t.Error("expected customer without a group to be accepted")
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	day := time.Date(1992, 1, 2, 12, 0, 0, 0, time.UTC)
+
+	reg, err := registry.New(
+		registry.Profile{CustomerID: 9050, Status: registry.StatusActive, KYCLevel: "basic"},
+		registry.Profile{CustomerID: 9051, Status: registry.StatusActive, KYCLevel: "Full"},
+		registry.Profile{CustomerID: 9052, Status: registry.StatusFrozen},
+		registry.Profile{CustomerID: 9053, Status: registry.StatusClosed},
+	)
+	if err != nil {
+		t.Fatalf("unable to build registry: %+v", err)
+	}
+
+	v := New(&conf.Config{
+		Store:    cache.New(),
+		Registry: reg,
+		Limits: &model.Limits{
+			DailyAmount:       5000,
+			DailyTransactions: 3,
+			WeeklyAmount:      20000,
+		},
+		KYCLimits: map[string]*model.Limits{
+			"basic": {DailyAmount: 1000},
+			"full":  {DailyAmount: 10000, WeeklyAmount: 50000},
+		},
+	})
+
+	// Account statuses other than active are rejected.
+	reasons := map[int]string{
+		9052: model.ReasonAccountFrozen,
+		9053: model.ReasonAccountClosed,
+		9054: model.ReasonUnknownCustomer,
+	}
+	for cid, reason := range reasons {
+		res := v.Validate(&model.Transaction{ID: 1, CustomerID: cid, LoadAmount: 100, Time: day})
+		if res.Accepted || res.Reason != reason {
+			t.Errorf("customer %d expected '%s' rejection, got reason '%s'", cid, reason, res.Reason)
+		}
+	}
+
+	// The KYC level picks the daily amount.
+	if res := v.Validate(&model.Transaction{ID: 1, CustomerID: 9050, LoadAmount: 1500, Time: day}); res.Reason != model.ReasonDailyAmountLimit {
+		t.Errorf("expected basic KYC daily limit rejection, got reason '%s'", res.Reason)
+	}
+	if res := v.Validate(&model.Transaction{ID: 1, CustomerID: 9051, LoadAmount: 7500, Time: day}); !res.Accepted {
+		t.Errorf("expected full KYC load to be accepted, got reason '%s'", res.Reason)
+	}
+
+	// Limits a level leaves unset fall back to the defaults.
+	if !v.IsWithinDailyLoadLimit(9050, day) {
+		t.Error("expected basic KYC customer to use the default daily transactions")
+	}
+}
+
+func TestKYCDisabledLimits(t *testing.T) {
+	day := time.Date(1992, 1, 2, 12, 0, 0, 0, time.UTC)
+
+	reg, err := registry.New(
+		registry.Profile{CustomerID: 9055, Status: registry.StatusActive, KYCLevel: "basic"},
+		registry.Profile{CustomerID: 9056, Status: registry.StatusActive, KYCLevel: "trusted"},
+	)
+	if err != nil {
+		t.Fatalf("unable to build registry: %+v", err)
+	}
+
+	// Both customers loaded a minute ago.
+	store := cache.New()
+	for _, cid := range []int{9055, 9056} {
+		store.Customers[cid] = []model.Result{
+			{ID: 1, CustomerID: cid, LoadAmount: 100, Time: day.Add(-time.Minute), Accepted: true},
+		}
+	}
+
+	v := New(&conf.Config{
+		Registry: reg,
+		Store:    store,
+		Limits: &model.Limits{
+			DailyAmount:       5000,
+			DailyTransactions: 3,
+			WeeklyAmount:      20000,
+			VelocityLoads:     1,
+			VelocityWindow:    10 * time.Minute,
+		},
+		KYCLimits: map[string]*model.Limits{
+			"basic":   {},
+			"trusted": {Explicit: map[string]bool{"velocity_loads": true}},
+		},
+	})
+
+	// An explicit zero disables the default velocity limit for the level.
+	if res := v.Validate(&model.Transaction{ID: 2, CustomerID: 9055, LoadAmount: 100, Time: day}); res.Reason != model.ReasonVelocityLimit {
+		t.Errorf("expected basic KYC velocity limit rejection, got reason '%s'", res.Reason)
+	}
+	if res := v.Validate(&model.Transaction{ID: 2, CustomerID: 9056, LoadAmount: 100, Time: day}); !res.Accepted {
+		t.Errorf("expected trusted KYC load to be accepted, got reason '%s'", res.Reason)
+	}
+}
+
+func TestMaxBalance(t *testing.T) {
+	day := time.Date(1993, 1, 4, 12, 0, 0, 0, time.UTC)
+
+	store := cache.New()
+
+	// Start the customer from an opening balance with one earlier load, part
+	// of which was reversed, and one spend.
+	store.Customers[9060] = []model.Result{
+		{ID: 3, CustomerID: 9060, Type: model.TypeSpend, LoadAmount: 300, Time: day.Add(-time.Hour), Accepted: true},
+		{ID: 1, CustomerID: 9060, LoadAmount: 1000, ReversedAmount: 200, Time: day.Add(-48 * time.Hour), Accepted: true},
+	}
+
+	v := New(&conf.Config{
+		Store:    store,
+		Balances: balance.New(map[int]float64{9060: 500}),
+		Limits: &model.Limits{
+			DailyAmount:       5000,
+			DailyTransactions: 3,
+			WeeklyAmount:      20000,
+			MaxBalance:        2000,
+		},
+	})
+
+	// The balance is 500 + 800 - 300 = 1000, so 1000 more reaches the cap.
+	if !v.IsWithinMaxBalance(9060, day, 1000) {
+		t.Error("expected load up to the balance ceiling to be accepted")
+	}
+	res := v.Validate(&model.Transaction{ID: 4, CustomerID: 9060, LoadAmount: 1000.01, Time: day})
+	if res.Accepted || res.Reason != model.ReasonMaxBalance {
+		t.Errorf("expected max balance rejection, got reason '%s'", res.Reason)
+	}
+
+	// Spends can't take the balance below zero.
+	res = v.Validate(&model.Transaction{ID: 5, CustomerID: 9060, Type: model.TypeSpend, LoadAmount: 9000, Time: day})
+	if res.Accepted || res.Reason != model.ReasonInsufficientBalance {
+		t.Errorf("expected insufficient balance rejection, got reason '%s'", res.Reason)
+	}
+	res = v.Validate(&model.Transaction{ID: 7, CustomerID: 9060, Type: model.TypeSpend, LoadAmount: 1000, Time: day})
+	if !res.Accepted {
+		t.Errorf("expected spend of the whole balance to be accepted, got reason '%s'", res.Reason)
+	}
+	res = v.Validate(&model.Transaction{ID: 6, CustomerID: 9060, Type: model.TypeSpend, Time: day})
+	if res.Accepted || res.Reason != model.ReasonInvalidAmount {
+		t.Errorf("expected zero spend to be rejected, got reason '%s'", res.Reason)
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	day := time.Date(1994, 1, 4, 12, 0, 0, 0, time.UTC)
+	store := cache.New()
+	store.Customers[9070] = []model.Result{
+		{ID: 1, CustomerID: 9070, LoadAmount: 4000, Time: day.Add(-time.Hour), Accepted: true},
+	}
+
+	v := New(&conf.Config{
+		Store: store,
+		Limits: &model.Limits{
+			DailyAmount:       5000,
+			DailyTransactions: 1,
+			WeeklyAmount:      20000,
+		},
+	})
+
+	// Every rule is evaluated even after the first failure.
+	res, evals := v.Evaluate(&model.Transaction{ID: 2, CustomerID: 9070, LoadAmount: 1500, Time: day})
+	if res.Accepted || res.Reason != model.ReasonDailyLoadLimit {
+		t.Errorf("expected daily load limit rejection, got reason '%s'", res.Reason)
+	}
+
+	failed := map[string]model.Evaluation{}
+	for _, eval := range evals {
+		if !eval.Passed {
+			failed[eval.Rule] = eval
+		}
+	}
+	if len(evals) < 2 || len(failed) != 2 {
+		t.Errorf("expected 2 of every rule to fail, got '%+v'", evals)
+	}
+
+	// Window limits report how much was already used.
+	if eval := failed[model.ReasonDailyAmountLimit]; eval.Used != 4000 || eval.Limit != 5000 || eval.Remaining != 999.99 || eval.Count {
+		t.Errorf("expected $4000 of $5000 daily amount used and $999.99 left, got '%+v'", eval)
+	}
+	if eval := failed[model.ReasonDailyLoadLimit]; eval.Used != 1 || eval.Limit != 1 || eval.Remaining != 0 || !eval.Count {
+		t.Errorf("expected 1 of 1 daily loads used, got '%+v'", eval)
+	}
+
+	// The advertised remaining amount is accepted and a cent more isn't.
+	if !v.IsWithinDailyAmountLimit(9070, day, 999.99) || v.IsWithinDailyAmountLimit(9070, day, 1000) {
+		t.Error("expected the remaining daily amount to be the largest accepted load")
+	}
+
+	// Nothing is recorded by the evaluation.
+	if entries := store.Customers[9070]; len(entries) != 1 {
+		t.Errorf("expected 1 cached entry, got %d", len(entries))
+	}
+}
+
+func TestVerbose(t *testing.T) {
+	day := time.Date(1996, 1, 4, 12, 0, 0, 0, time.UTC)
+	store := cache.New()
+	store.Customers[9090] = []model.Result{
+		{ID: 1, CustomerID: 9090, LoadAmount: 4500, Time: day.Add(-time.Hour), Accepted: true},
+	}
+
+	v := New(&conf.Config{
+		Store:   store,
+		Verbose: true,
+		Limits: &model.Limits{
+			DailyAmount:       5000,
+			DailyTransactions: 3,
+			WeeklyAmount:      20000,
+		},
+	})
+
+	// The usage before the load is captured and output with the reason.
+	res := v.Validate(&model.Transaction{ID: 2, CustomerID: 9090, LoadAmount: 600, Time: day})
+	b, err := res.MarshalJSON()
+	if err != nil {
+		t.Fatalf("unable to marshal result: %+v", err)
+	}
+	expected := `{"id":"2","customer_id":"9090","accepted":false,"reason":"daily_amount_limit","daily_used":4500,"daily_limit":5000,"weekly_used":4500,"weekly_limit":20000,"daily_count":1}`
+	if string(b) != expected {
+		t.Errorf("expected '%s', got '%s'", expected, b)
+	}
+}
+
+func TestExplain(t *testing.T) {
+	day := time.Date(1997, 1, 2, 12, 0, 0, 0, time.UTC)
+	store := cache.New()
+	store.Customers[9100] = []model.Result{
+		{ID: 3, CustomerID: 9100, LoadAmount: 6000, Time: day.Add(-time.Hour), Accepted: false, Reason: model.ReasonDailyAmountLimit},
+		{ID: 2, CustomerID: 9100, LoadAmount: 3000, Time: day.Add(-2 * time.Hour), Accepted: true},
+		{ID: 1, CustomerID: 9100, LoadAmount: 1000, Time: day.Add(-24 * time.Hour), Accepted: true},
+	}
+
+	v := New(&conf.Config{
+		Store: store,
+		Limits: &model.Limits{
+			DailyAmount:       5000,
+			DailyTransactions: 3,
+			WeeklyAmount:      20000,
+		},
+	})
+
+	exp := v.Explain(&model.Transaction{ID: 4, CustomerID: 9100, LoadAmount: 2500, Time: day})
+	if exp.Result.Accepted || exp.Result.Reason != model.ReasonDailyAmountLimit {
+		t.Errorf("expected daily amount limit rejection, got reason '%s'", exp.Result.Reason)
+	}
+
+	// The daily window holds both of today's loads, but only the accepted one
+	// counts toward the amount.
+	daily := exp.Windows[0]
+	if !daily.Start.Equal(timeToDayStart(day)) || daily.Amount != 3000 || daily.Count != 2 || len(daily.Entries) != 2 {
+		t.Errorf("expected $3000 over 2 loads today, got '%+v'", daily)
+	}
+	if e := daily.Entries[1]; e.ID != 3 || e.InSum || !e.InCount {
+		t.Errorf("expected rejected load to count toward the count only, got '%+v'", e)
+	}
+
+	// The weekly window also holds yesterday's load.
+	if weekly := exp.Windows[1]; weekly.Amount != 4000 || weekly.Count != 3 {
+		t.Errorf("expected $4000 over 3 loads this week, got '%+v'", weekly)
+	}
+}